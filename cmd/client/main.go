@@ -11,7 +11,13 @@ import (
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"gopkg.in/yaml.v3"
+
+	"github.com/transform-ia/mcp-tools/pkg/daemon"
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+	"github.com/transform-ia/mcp-tools/pkg/tools"
 )
 
 const minArgs = 2 // Minimum required command line arguments
@@ -29,7 +35,7 @@ type Config struct {
 	} `yaml:"tools"`
 }
 
-func logic() error {
+func logic(ctx context.Context) error {
 	fmt.Println("Starting MCP client...")
 
 	configFile := os.Args[1]
@@ -74,16 +80,25 @@ func logic() error {
 	var (
 		index    int
 		envSlice = make([]string, len(config.Server.Env))
+		envNames = make([]string, len(config.Server.Env))
 	)
 
 	for k, v := range config.Server.Env {
+		// the values may be references to secrets
+		if secret.IsReference(v) {
+			if v, err = secret.Resolve(ctx, v); err != nil {
+				return errors.Wrapf(err, "environment variable %s", k)
+			}
+		}
+
 		envSlice[index] = fmt.Sprintf("%s=%s", k, v)
+		envNames[index] = k
 		index++
 	}
 
 	fmt.Printf("Creating MCP client via stdio for command: %s\n", absExec)
 	fmt.Printf("With arguments: %v\n", config.Server.Args)
-	fmt.Printf("Environment variables: %v\n", envSlice)
+	fmt.Printf("Environment variables: %v\n", envNames)
 
 	// Use NewStdioMCPClient - this handles process launch and communication
 	cli, err := client.NewStdioMCPClient(absExec, envSlice, config.Server.Args...)
@@ -95,7 +110,7 @@ func logic() error {
 	fmt.Println("Initializing MCP client...")
 
 	// Use the new client variable 'cli' and qualify InitializeRequest with mcp package
-	if _, err = cli.Initialize(context.Background(), mcp.InitializeRequest{}); err != nil {
+	if _, err = cli.Initialize(ctx, mcp.InitializeRequest{}); err != nil {
 		return errors.Wrap(err, "client.Initialize")
 	}
 
@@ -104,6 +119,8 @@ func logic() error {
 	// Run tools from config
 	fmt.Printf("Executing %d tools from config...\n", len(config.Tools))
 
+	tracer := otel.Tracer("mcp-client")
+
 	for _, tool := range config.Tools {
 		fmt.Printf("Executing tool: %s with args: %v\n", tool.Name, tool.Arg)
 
@@ -124,11 +141,24 @@ func logic() error {
 		req.Params.Name = tool.Name
 		req.Params.Arguments = argsMap
 
-		result, err := cli.CallTool(context.Background(), req)
+		// the server continue the trace propagated in the _meta of the request
+		callCtx, span := tracer.Start(ctx, tool.Name)
+		tools.InjectTraceContext(callCtx, &req)
+
+		result, err := cli.CallTool(callCtx, req)
 		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+
 			return errors.Wrapf(err, "failed to call tool %s", tool.Name)
 		}
 
+		if result.IsError {
+			span.SetStatus(codes.Error, "tool returned an error result")
+		}
+
+		span.End()
+
 		// Use type assertion via AsTextContent to get text result
 		var resultText string
 
@@ -155,8 +185,5 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := logic(); err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
-	}
+	daemon.RunDaemon("mcp-client", "1.0.0", logic)
 }
@@ -17,6 +17,7 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/transform-ia/mcp-tools/pkg/daemon"
+	"github.com/transform-ia/mcp-tools/pkg/telemetry"
 )
 
 func main() {
@@ -35,7 +36,7 @@ func main() {
 	}()
 
 	// Run the daemon with required parameters
-	daemon.RunDaemon("daemon", "1.0.0", func() error {
+	daemon.RunDaemon("daemon", "1.0.0", func(ctx context.Context) error {
 		// Create instruments
 		meter := meterProvider.Meter("daemon")
 		counter, err := meter.Int64Counter("daemon.operations.count")
@@ -50,13 +51,15 @@ func main() {
 
 		// Main loop with telemetry
 		tracer := tracerProvider.Tracer("daemon")
+		logger := telemetry.LoggerFromContext(ctx)
+
 		for {
-			ctx, span := tracer.Start(context.Background(), "daemon.operation")
+			opCtx, span := tracer.Start(ctx, "daemon.operation")
 			
 			// Record metrics
 			startTime := time.Now()
-			counter.Add(ctx, 1)
-			histogram.Record(ctx, time.Since(startTime).Seconds())
+			counter.Add(opCtx, 1)
+			histogram.Record(opCtx, time.Since(startTime).Seconds())
 
 			// Add span attributes and events
 			span.SetAttributes(attribute.String("status", "processing"))
@@ -65,12 +68,19 @@ func main() {
 			))
 
 			// Simulate work
-			time.Sleep(5 * time.Second)
+			select {
+			case <-ctx.Done():
+				span.SetAttributes(attribute.String("status", "cancelled"))
+				span.End()
+
+				return nil
+			case <-time.After(5 * time.Second):
+			}
 
 			span.SetAttributes(attribute.String("status", "completed"))
+			logger.InfoContext(opCtx, "operation completed")
 			span.End()
 		}
-		return nil
 	})
 }
 
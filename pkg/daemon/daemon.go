@@ -5,40 +5,61 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/pkg/errors"
 
 	"github.com/transform-ia/mcp-tools/pkg/telemetry"
+	"github.com/transform-ia/mcp-tools/pkg/tools"
 )
 
-func runDaemon(serviceName, version string, logic func() error) error {
-	ctx := context.Background()
+func runDaemon(serviceName, version string, logic func(context.Context) error, opts ...telemetry.Option) error {
+	// the telemetry must not write to stdout from its initialization until its
+	// last flush, an invalid transport being reported by tools.Serve
+	if transport, err := tools.GetTransport(); err == nil && transport == tools.TransportStdio {
+		defer telemetry.ProtectStdout()()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	shutdown, err := telemetry.InitTelemetry(ctx, serviceName, version)
+	// telemetry must be flushed even once ctx is cancelled by a signal
+	shutdownCtx := context.WithoutCancel(ctx)
+
+	tel, err := telemetry.InitTelemetry(ctx, serviceName, version, opts...)
 	if err != nil {
 		return errors.Wrap(err, "telemetry.InitTelemetry")
 	}
 
-	if err = logic(); err != nil {
-		sErr := shutdown(ctx)
+	ctx = telemetry.ContextWithLogger(ctx, tel.Logger)
+	ctx = telemetry.ContextWithTelemetry(ctx, tel)
+
+	if err = logic(ctx); err != nil {
+		sErr := tel.Shutdown(shutdownCtx)
 		if sErr != nil {
-			fmt.Println(sErr.Error())
+			fmt.Fprintln(os.Stderr, sErr.Error())
 		}
 
 		return errors.Wrap(err, "logic")
 	}
 
-	if err = shutdown(ctx); err != nil {
+	if err = tel.Shutdown(shutdownCtx); err != nil {
 		return errors.Wrap(err, "shutdown")
 	}
 
 	return nil
 }
 
-// RunDaemon is entrypoint for a deamon
-func RunDaemon(serviceName, version string, logic func() error) {
-	if err := runDaemon(serviceName, version, logic); err != nil {
-		fmt.Println(err.Error())
+// RunDaemon is entrypoint for a deamon, the context given to logic is
+// cancelled on SIGINT or SIGTERM and carry the telemetry initialized with opts
+// and its logger (see telemetry.FromContext and telemetry.LoggerFromContext),
+// which is flushed once logic return. When tools.Serve is to use the stdio
+// transport (see tools.GetTransport), the console telemetry exporters write to
+// stderr (see telemetry.ProtectStdout). The errors are printed on stderr.
+func RunDaemon(serviceName, version string, logic func(context.Context) error, opts ...telemetry.Option) {
+	if err := runDaemon(serviceName, version, logic, opts...); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,151 @@
+package secret
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// Redacted replace the secrets
+	Redacted = "[REDACTED]"
+	// minRedactedLength is the length of the shortest secret redacted,
+	// shorter ones would redact unrelated text
+	minRedactedLength = 4
+)
+
+// redactor replace the secrets resolved so far
+type redactor struct {
+	mu       sync.RWMutex
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// redacted are the secrets resolved by the process
+var redacted = &redactor{secrets: make(map[string]struct{})}
+
+// add a secret
+func (r *redactor) add(secret string) {
+	if len(secret) < minRedactedLength {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.secrets[secret]; exists {
+		return
+	}
+
+	r.secrets[secret] = struct{}{}
+
+	// the longest first, so that a secret prefixing another one does not leave
+	// the end of the other one visible
+	secrets := slices.SortedFunc(maps.Keys(r.secrets), func(a, b string) int {
+		return cmp.Or(len(b)-len(a), strings.Compare(a, b))
+	})
+
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, known := range secrets {
+		pairs = append(pairs, known, Redacted)
+	}
+
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// redact the secrets of text
+func (r *redactor) redact(text string) string {
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+
+	if replacer == nil {
+		return text
+	}
+
+	return replacer.Replace(text)
+}
+
+// AddRedacted redact secret from then on, like the secrets resolved by
+// Resolve
+func AddRedacted(secret string) {
+	redacted.add(secret)
+}
+
+// Redact replace the secrets resolved so far in text by Redacted
+func Redact(text string) string {
+	return redacted.redact(text)
+}
+
+// redactingHandler is a slog.Handler redacting the records of its handler
+type redactingHandler struct {
+	handler slog.Handler
+}
+
+// NewRedactingHandler create a slog.Handler redacting the secrets from the
+// message and the attributes of the records before handing them to handler
+func NewRedactingHandler(handler slog.Handler) slog.Handler {
+	return &redactingHandler{handler: handler}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redactedRecord := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		redactedRecord.AddAttrs(redactAttr(attr))
+
+		return true
+	})
+
+	return h.handler.Handle(ctx, redactedRecord) //nolint:wrapcheck
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for index, attr := range attrs {
+		redactedAttrs[index] = redactAttr(attr)
+	}
+
+	return &redactingHandler{handler: h.handler.WithAttrs(redactedAttrs)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{handler: h.handler.WithGroup(name)}
+}
+
+// redactAttr redact the secrets of the value of attr, formatting the values
+// that are not strings, numbers, booleans, durations or times
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redactedGroup := make([]any, len(group))
+
+		for index, member := range group {
+			redactedGroup[index] = redactAttr(member)
+		}
+
+		return slog.Group(attr.Key, redactedGroup...)
+	case slog.KindAny:
+		formatted := value.String()
+		if redactedValue := Redact(formatted); redactedValue != formatted {
+			return slog.String(attr.Key, redactedValue)
+		}
+
+		return slog.Attr{Key: attr.Key, Value: value}
+	default:
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+}
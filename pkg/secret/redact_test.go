@@ -0,0 +1,60 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	AddRedacted("redact-secret")
+	AddRedacted("abc")
+	AddRedacted("prefix")
+	AddRedacted("prefix-longer")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "no secret", text: "hello", want: "hello"},
+		{name: "secret", text: "token redact-secret used", want: "token [REDACTED] used"},
+		{name: "repeated secret", text: "redact-secret/redact-secret", want: "[REDACTED]/[REDACTED]"},
+		{name: "short secret kept", text: "abc", want: "abc"},
+		{name: "prefixing secret", text: "prefix-longer prefix", want: "[REDACTED] [REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Redact(tt.text))
+		})
+	}
+}
+
+func TestRedactingHandler(t *testing.T) {
+	AddRedacted("handler-secret")
+
+	var buffer bytes.Buffer
+
+	logger := slog.New(NewRedactingHandler(slog.NewTextHandler(&buffer, nil))).
+		With("token", "handler-secret")
+
+	logger.Info("using handler-secret",
+		"error", errors.New("invalid handler-secret"),
+		"count", 1,
+		slog.Group("request", "header", "Bearer handler-secret"),
+		"secret", New("inline-secret"),
+	)
+
+	assert.NotContains(t, buffer.String(), "handler-secret")
+	assert.NotContains(t, buffer.String(), "inline-secret")
+	assert.Contains(t, buffer.String(), `msg="using [REDACTED]"`)
+	assert.Contains(t, buffer.String(), "token=[REDACTED]")
+	assert.Contains(t, buffer.String(), `error="invalid [REDACTED]"`)
+	assert.Contains(t, buffer.String(), "count=1")
+	assert.Contains(t, buffer.String(), `request.header="Bearer [REDACTED]"`)
+	assert.Contains(t, buffer.String(), "secret=[REDACTED]")
+}
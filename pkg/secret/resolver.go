@@ -0,0 +1,179 @@
+// Package secret resolve the secrets referenced by configuration values, like
+// "file:///run/secrets/token", "env://API_TOKEN" or "exec://pass show api",
+// and redact the resolved values from logs, telemetry and error messages
+package secret
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schemes of the references resolved by default
+const (
+	// SchemeFile read the secret from a file, like file:///run/secrets/token
+	SchemeFile = "file"
+	// SchemeEnv read the secret from an environment variable, like
+	// env://API_TOKEN
+	SchemeEnv = "env"
+	// SchemeExec read the secret from the standard output of a command, like
+	// exec://pass show api
+	SchemeExec = "exec"
+)
+
+const (
+	schemeSeparator = "://"
+	// defaultExecTimeout is how long the command of an ExecResolver may run
+	defaultExecTimeout = 10 * time.Second
+)
+
+// Resolver resolve the references of a scheme, given without their
+// "<scheme>://" prefix
+type Resolver interface {
+	Resolve(ctx context.Context, reference string) (string, error)
+}
+
+// ResolverFunc implements Resolver with a function
+type ResolverFunc func(ctx context.Context, reference string) (string, error)
+
+// Resolve call f
+func (f ResolverFunc) Resolve(ctx context.Context, reference string) (string, error) {
+	return f(ctx, reference)
+}
+
+// FileResolver read the secret from the file at the reference path, without
+// its trailing newline
+type FileResolver struct{}
+
+// Resolve implements Resolver
+func (FileResolver) Resolve(_ context.Context, reference string) (string, error) {
+	content, err := os.ReadFile(reference)
+	if err != nil {
+		return "", errors.Wrap(err, "os.ReadFile")
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// EnvResolver read the secret from the environment variable named by the
+// reference, which must be defined
+type EnvResolver struct{}
+
+// Resolve implements Resolver
+func (EnvResolver) Resolve(_ context.Context, reference string) (string, error) {
+	value, found := os.LookupEnv(reference)
+	if !found {
+		return "", errors.Errorf("undefined environment variable %q", reference)
+	}
+
+	return value, nil
+}
+
+// ExecResolver read the secret from the standard output of a command,
+// without its trailing newline. The command is Command with the reference
+// appended as its last argument, or the reference split on spaces when
+// Command is empty. It is not run by a shell.
+type ExecResolver struct {
+	Command []string
+	// Timeout of the command, 10 seconds when zero
+	Timeout time.Duration
+}
+
+// Resolve implements Resolver
+func (resolver ExecResolver) Resolve(ctx context.Context, reference string) (string, error) {
+	commandLine := append(append([]string{}, resolver.Command...), reference)
+	if len(resolver.Command) == 0 {
+		commandLine = strings.Fields(reference)
+	}
+
+	if len(commandLine) == 0 {
+		return "", errors.New("empty command")
+	}
+
+	timeout := resolver.Timeout
+	if timeout <= 0 {
+		timeout = defaultExecTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	//nolint:gosec
+	command := exec.CommandContext(ctx, commandLine[0], commandLine[1:]...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	// the output is not part of the error, it could be the secret
+	if err := command.Run(); err != nil {
+		return "", errors.Wrapf(err, "command %q", commandLine[0])
+	}
+
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+var (
+	resolversMutex sync.RWMutex
+	resolvers      = map[string]Resolver{
+		SchemeFile: FileResolver{},
+		SchemeEnv:  EnvResolver{},
+		SchemeExec: ExecResolver{},
+	}
+)
+
+// RegisterResolver resolve the references of scheme with resolver, replacing
+// the previous one, like an ExecResolver running a vault client for
+// "vault://"
+func RegisterResolver(scheme string, resolver Resolver) {
+	resolversMutex.Lock()
+	defer resolversMutex.Unlock()
+
+	resolvers[scheme] = resolver
+}
+
+// resolverOf return the resolver of the scheme of value and the reference,
+// nil when value is not a reference of a registered scheme
+func resolverOf(value string) (Resolver, string) {
+	scheme, reference, found := strings.Cut(value, schemeSeparator)
+	if !found {
+		return nil, ""
+	}
+
+	resolversMutex.RLock()
+	defer resolversMutex.RUnlock()
+
+	return resolvers[scheme], reference
+}
+
+// IsReference tell if value is a reference of a registered scheme
+func IsReference(value string) bool {
+	resolver, _ := resolverOf(value)
+
+	return resolver != nil
+}
+
+// Resolve return the secret referenced by value, or value itself when it is
+// not a reference of a registered scheme. The resolved secret is redacted by
+// Redact from then on. The errors do not contain the secret.
+func Resolve(ctx context.Context, value string) (string, error) {
+	resolver, reference := resolverOf(value)
+	if resolver == nil {
+		return value, nil
+	}
+
+	resolved, err := resolver.Resolve(ctx, reference)
+	if err != nil {
+		return "", errors.Wrapf(err, "resolving %q", value)
+	}
+
+	AddRedacted(resolved)
+
+	return resolved, nil
+}
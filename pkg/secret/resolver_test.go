@@ -0,0 +1,85 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("file-secret\n"), 0o600))
+
+	t.Setenv("SECRET_TEST_TOKEN", "env-secret")
+
+	RegisterResolver("test", ResolverFunc(func(_ context.Context, reference string) (string, error) {
+		if reference == "missing" {
+			return "", errors.New("not found")
+		}
+
+		return "test-" + reference, nil
+	}))
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "inline", value: "inline-secret", want: "inline-secret"},
+		{name: "unknown scheme", value: "https://example.com", want: "https://example.com"},
+		{name: "file", value: "file://" + path, want: "file-secret"},
+		{name: "missing file", value: "file://" + path + ".missing", wantErr: true},
+		{name: "env", value: "env://SECRET_TEST_TOKEN", want: "env-secret"},
+		{name: "undefined env", value: "env://SECRET_TEST_UNDEFINED", wantErr: true},
+		{name: "exec", value: "exec://echo exec-secret", want: "exec-secret"},
+		{name: "failing exec", value: "exec://false", wantErr: true},
+		{name: "empty exec", value: "exec://", wantErr: true},
+		{name: "registered", value: "test://secret", want: "test-secret"},
+		{name: "registered failure", value: "test://missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(t.Context(), tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// only the resolved references are redacted
+			if IsReference(tt.value) {
+				assert.Equal(t, Redacted, Redact(got))
+			} else {
+				assert.Equal(t, got, Redact(got))
+			}
+		})
+	}
+}
+
+func TestExecResolver(t *testing.T) {
+	resolver := ExecResolver{Command: []string{"echo", "-n"}}
+
+	got, err := resolver.Resolve(t.Context(), "with spaces")
+	require.NoError(t, err)
+	assert.Equal(t, "with spaces", got)
+
+	_, err = ExecResolver{Timeout: 1}.Resolve(t.Context(), "sleep 1")
+	require.Error(t, err)
+}
+
+func TestIsReference(t *testing.T) {
+	assert.True(t, IsReference("env://TOKEN"))
+	assert.True(t, IsReference("file:///run/secrets/token"))
+	assert.False(t, IsReference("https://example.com"))
+	assert.False(t, IsReference("token"))
+}
@@ -0,0 +1,163 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// resolution is the cached resolution of a Secret
+type resolution struct {
+	mu       sync.Mutex
+	resolved bool
+	value    string
+}
+
+// Secret is a configuration field whose value is a secret, given inline or
+// as a reference resolved on first use (see Resolve). It is redacted when
+// formatted or logged, and marshaled as its reference. Copies share the
+// resolution.
+type Secret struct {
+	value      string
+	resolution *resolution
+}
+
+// New create a Secret of value, a secret or a reference to it
+func New(value string) Secret {
+	return Secret{value: value, resolution: &resolution{}}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (secret *Secret) UnmarshalText(text []byte) error {
+	*secret = New(string(text))
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, redacting the secrets given
+// inline
+func (secret Secret) MarshalText() ([]byte, error) {
+	if secret.value == "" || IsReference(secret.value) {
+		return []byte(secret.value), nil
+	}
+
+	return []byte(Redacted), nil
+}
+
+// String implements fmt.Stringer, redacting the secret
+func (Secret) String() string {
+	return Redacted
+}
+
+// GoString implements fmt.GoStringer, redacting the secret
+func (Secret) GoString() string {
+	return Redacted
+}
+
+// LogValue implements slog.LogValuer, redacting the secret
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// IsZero tell if the secret is not set
+func (secret Secret) IsZero() bool {
+	return secret.value == ""
+}
+
+// Resolve the secret once, its value being cached and redacted by Redact
+// even when given inline. A failure is not cached.
+func (secret Secret) Resolve(ctx context.Context) (string, error) {
+	if secret.resolution == nil {
+		return secret.value, nil
+	}
+
+	secret.resolution.mu.Lock()
+	defer secret.resolution.mu.Unlock()
+
+	if !secret.resolution.resolved {
+		value, err := Resolve(ctx, secret.value)
+		if err != nil {
+			return "", err
+		}
+
+		AddRedacted(value)
+
+		secret.resolution.value = value
+		secret.resolution.resolved = true
+	}
+
+	return secret.resolution.value, nil
+}
+
+// Value return the value of the secret resolved by Resolve or ResolveAll,
+// empty when it is not resolved yet
+func (secret Secret) Value() string {
+	if secret.resolution == nil {
+		return secret.value
+	}
+
+	secret.resolution.mu.Lock()
+	defer secret.resolution.mu.Unlock()
+
+	return secret.resolution.value
+}
+
+// secretType is the type of Secret
+var secretType = reflect.TypeFor[Secret]()
+
+// ResolveAll resolve the Secret fields of value, a struct or a pointer to a
+// struct, including the ones of nested structs, pointers, slices and maps
+func ResolveAll(ctx context.Context, value any) error {
+	return resolveValue(ctx, reflect.ValueOf(value), "")
+}
+
+// resolveValue resolve the secrets of value found at path
+func resolveValue(ctx context.Context, value reflect.Value, path string) error {
+	if value.Type() == secretType {
+		if _, err := value.Interface().(Secret).Resolve(ctx); err != nil {
+			return errors.Wrapf(err, "secret %s", path)
+		}
+
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+
+		return resolveValue(ctx, value.Elem(), path)
+	case reflect.Struct:
+		for index := range value.NumField() {
+			field := value.Type().Field(index)
+			if !field.IsExported() {
+				continue
+			}
+
+			if err := resolveValue(ctx, value.Field(index), path+"."+field.Name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for index := range value.Len() {
+			if err := resolveValue(ctx, value.Index(index), path+"["+strconv.Itoa(index)+"]"); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for iterator := value.MapRange(); iterator.Next(); {
+			if err := resolveValue(ctx, iterator.Value(), path+"["+fmt.Sprint(iterator.Key())+"]"); err != nil {
+				return err
+			}
+		}
+	default:
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretFormat(t *testing.T) {
+	inline := New("format-secret")
+
+	assert.Equal(t, Redacted, fmt.Sprint(inline))
+	assert.NotContains(t, fmt.Sprintf("%v %+v %#v %s", inline, inline, inline, inline), "format-secret")
+	assert.NotContains(t, fmt.Sprintf("%+v", struct{ Token Secret }{inline}), "format-secret")
+}
+
+func TestSecretMarshalText(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "empty", value: "", want: ""},
+		{name: "inline", value: "marshal-secret", want: Redacted},
+		{name: "reference", value: "env://TOKEN", want: "env://TOKEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.value).MarshalText()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSecretResolve(t *testing.T) {
+	t.Setenv("SECRET_TEST_RESOLVE", "first")
+
+	var decoded struct {
+		Token Secret `json:"token"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"token":"env://SECRET_TEST_RESOLVE"}`), &decoded))
+	assert.False(t, decoded.Token.IsZero())
+	assert.Empty(t, decoded.Token.Value())
+
+	copied := decoded.Token
+
+	got, err := decoded.Token.Resolve(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "first", got)
+	assert.Equal(t, "first", copied.Value())
+
+	// the resolution is cached
+	t.Setenv("SECRET_TEST_RESOLVE", "second")
+
+	got, err = copied.Resolve(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "first", got)
+
+	// an inline secret is redacted once resolved
+	inline := New("inline-resolved-secret")
+	assert.Equal(t, "inline-resolved-secret", Redact("inline-resolved-secret"))
+
+	got, err = inline.Resolve(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, "inline-resolved-secret", got)
+	assert.Equal(t, Redacted, Redact("inline-resolved-secret"))
+
+	// the zero Secret is empty
+	got, err = Secret{}.Resolve(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.True(t, Secret{}.IsZero())
+}
+
+func TestResolveAll(t *testing.T) {
+	type endpoint struct {
+		Token Secret
+	}
+
+	type configuration struct {
+		Password  Secret
+		Endpoint  *endpoint
+		Endpoints []endpoint
+		Named     map[string]Secret
+		Missing   *endpoint
+		Any       any
+		hidden    Secret
+	}
+
+	t.Setenv("SECRET_TEST_ALL", "resolved")
+
+	tests := []struct {
+		name    string
+		value   *configuration
+		wantErr string
+	}{
+		{
+			name: "all",
+			value: &configuration{
+				Password:  New("env://SECRET_TEST_ALL"),
+				Endpoint:  &endpoint{Token: New("env://SECRET_TEST_ALL")},
+				Endpoints: []endpoint{{Token: New("env://SECRET_TEST_ALL")}},
+				Named:     map[string]Secret{"name": New("env://SECRET_TEST_ALL")},
+				Any:       endpoint{Token: New("env://SECRET_TEST_ALL")},
+				hidden:    New("env://SECRET_TEST_UNDEFINED"),
+			},
+		},
+		{
+			name:    "nested error",
+			value:   &configuration{Endpoints: []endpoint{{}, {Token: New("env://SECRET_TEST_UNDEFINED")}}},
+			wantErr: "secret .Endpoints[1].Token",
+		},
+		{
+			name:    "map error",
+			value:   &configuration{Named: map[string]Secret{"name": New("env://SECRET_TEST_UNDEFINED")}},
+			wantErr: "secret .Named[name]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ResolveAll(t.Context(), tt.value)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "resolved", tt.value.Password.Value())
+			assert.Equal(t, "resolved", tt.value.Endpoint.Token.Value())
+			assert.Equal(t, "resolved", tt.value.Endpoints[0].Token.Value())
+			assert.Equal(t, "resolved", tt.value.Named["name"].Value())
+			assert.Equal(t, "resolved", tt.value.Any.(endpoint).Token.Value())
+		})
+	}
+}
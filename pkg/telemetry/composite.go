@@ -0,0 +1,377 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OTLP environment variable keys
+const (
+	// otelExporterOTLPLogsProtoEnvKey is the environment variable key for logs protocol
+	otelExporterOTLPLogsProtoEnvKey = "OTEL_EXPORTER_OTLP_LOGS_PROTOCOL"
+	// otelExporterOTLPTracesProtoEnvKey is the environment variable key for traces protocol
+	otelExporterOTLPTracesProtoEnvKey = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+	// otelExporterOTLPMetricsProtoEnvKey is the environment variable key for metrics protocol
+	otelExporterOTLPMetricsProtoEnvKey = "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL"
+	// otelExporterOTLPProtoEnvKey is the general OTLP protocol environment variable key
+	otelExporterOTLPProtoEnvKey = "OTEL_EXPORTER_OTLP_PROTOCOL"
+)
+
+// Exporter selection environment variable keys
+const (
+	otelTracesExporterEnvKey  = "OTEL_TRACES_EXPORTER"
+	otelLogsExporterEnvKey    = "OTEL_LOGS_EXPORTER"
+	otelMetricsExporterEnvKey = "OTEL_METRICS_EXPORTER"
+)
+
+// Exporter names and OTLP protocols
+const (
+	exporterConsole       = "console"
+	exporterOTLP          = "otlp"
+	exporterNone          = "none"
+	exporterListSeparator = ","
+	protoGRPC             = "grpc"
+	protoHTTPProtobuf     = "http/protobuf"
+	invalidProtoMessage   = "invalid OTLP protocol - should be one of ['grpc', 'http/protobuf']"
+)
+
+// defaultChildTimeout bound each call to a child of a composite exporter, so
+// one hung backend cannot block the others
+const defaultChildTimeout = 10 * time.Second
+
+// shutdowner is implemented by the exporters of all signals
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+func wrapMultiErrors(errs []error) error {
+	lenErrs := len(errs)
+
+	if lenErrs == 0 {
+		return nil
+	}
+
+	errsString := make([]string, lenErrs)
+
+	for index, err := range errs {
+		errsString[index] = err.Error()
+	}
+
+	return errors.Errorf("error(s) occurred: %s", strings.Join(errsString, ","))
+}
+
+// otlpProtocol return the OTLP protocol of a signal, defaulting to the
+// general one then to http/protobuf
+func otlpProtocol(signalEnvKey string) string {
+	proto := os.Getenv(signalEnvKey)
+	if proto == "" {
+		proto = os.Getenv(otelExporterOTLPProtoEnvKey)
+	}
+
+	// Fallback to default, http/protobuf.
+	if proto == "" {
+		proto = protoHTTPProtobuf
+	}
+
+	return proto
+}
+
+// exporterNames parse a list of exporters like "console,otlp,file", without
+// duplicates nor "none" entries
+func exporterNames(value string) []string {
+	var (
+		names []string
+		seen  = make(map[string]bool)
+	)
+
+	for name := range strings.SplitSeq(value, exporterListSeparator) {
+		name = strings.TrimSpace(name)
+		if name == "" || name == exporterNone || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// isCompositeExporter tell if the exporter set by envKey is to be exported
+// by a composite, instead of a single exporter known to autoexport: a list, or
+// the console exporter which must write to our console output
+func isCompositeExporter(envKey string) bool {
+	value := os.Getenv(envKey)
+
+	return strings.Contains(value, exporterListSeparator) ||
+		strings.TrimSpace(value) == exporterConsole
+}
+
+// busyPolicy tell how a fan out treat the children still running a call,
+// maybe one that timed out
+type busyPolicy int
+
+const (
+	// skipBusy skip them with an error
+	skipBusy busyPolicy = iota
+	// waitBusy wait for them until their timeout or ctx is done
+	waitBusy
+	// deferBusy wait for them like waitBusy, then call them once their call
+	// return when it is still running
+	deferBusy
+)
+
+// composite fan out calls to children exporters
+type composite[T shutdowner] struct {
+	names    []string
+	children []T
+	// timeout of each call to a child, zero means no timeout
+	timeout time.Duration
+	// calls hold a token while a child is running a call, maybe one that
+	// timed out, so that it is not called concurrently
+	calls []chan struct{}
+}
+
+// newCompositeOf create a composite of children named by names
+func newCompositeOf[T shutdowner](names []string, children []T, timeout time.Duration) *composite[T] {
+	calls := make([]chan struct{}, len(children))
+	for index := range calls {
+		calls[index] = make(chan struct{}, 1)
+	}
+
+	return &composite[T]{
+		names:    names,
+		children: children,
+		timeout:  timeout,
+		calls:    calls,
+	}
+}
+
+// newComposite create a composite of the exporters named, created with
+// factories, shutting down the created ones if one fail
+func newComposite[T shutdowner](
+	ctx context.Context,
+	envKey string,
+	names []string,
+	factories map[string]func(context.Context) (T, error),
+) (*composite[T], error) {
+	children := make([]T, 0, len(names))
+
+	for _, name := range names {
+		factory, exists := factories[name]
+		if !exists {
+			shutdownChildren(ctx, children)
+
+			return nil, errors.Errorf("unknown exporter %q in %s", name, envKey)
+		}
+
+		child, err := factory(ctx)
+		if err != nil {
+			shutdownChildren(ctx, children)
+
+			return nil, errors.Wrap(err, name)
+		}
+
+		children = append(children, child)
+	}
+
+	return newCompositeOf(names, children, defaultChildTimeout), nil
+}
+
+// shutdownChildren shutdown exporters that will not be used
+func shutdownChildren[T shutdowner](ctx context.Context, children []T) {
+	for _, child := range children {
+		_ = child.Shutdown(ctx)
+	}
+}
+
+// fanOut call on all children concurrently the operation returned for each
+// one by prepare, which copy the data of the call before any child is
+// started since the SDK reuse it once the exporter return while a child that
+// timed out may still read it. The error of each failing child is reported by
+// its name. The wait for a child end with its timeout or ctx even if the child
+// ignore its context, and a child still running a previous call is treated
+// according to busy.
+func (c *composite[T]) fanOut(
+	ctx context.Context,
+	busy busyPolicy,
+	prepare func() func(context.Context, T) error,
+) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(c.children))
+	)
+
+	for index, child := range c.children {
+		if busy == skipBusy && !c.tryAcquire(index) {
+			errs[index] = errors.Errorf("exporter %s: busy with a previous call", c.names[index])
+
+			continue
+		}
+
+		operation := prepare()
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if busy != skipBusy {
+				if err := c.acquire(ctx, index); err != nil {
+					if busy == deferBusy {
+						go c.callLater(ctx, index, child, operation)
+					}
+
+					errs[index] = errors.Wrapf(err, "exporter %s", c.names[index])
+
+					return
+				}
+			}
+
+			if err := c.call(ctx, index, child, operation); err != nil {
+				errs[index] = errors.Wrapf(err, "exporter %s", c.names[index])
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var failures []error
+
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	return wrapMultiErrors(failures)
+}
+
+// tryAcquire mark the child at index as running a call unless it already is
+func (c *composite[T]) tryAcquire(index int) bool {
+	select {
+	case c.calls[index] <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire mark the child at index as running a call, waiting for its
+// previous call until its timeout or ctx is done
+func (c *composite[T]) acquire(ctx context.Context, index int) error {
+	if c.tryAcquire(index) {
+		return nil
+	}
+
+	var timeout <-chan time.Time
+
+	if c.timeout > 0 {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case c.calls[index] <- struct{}{}:
+		return nil
+	case <-timeout:
+		return errors.Wrap(context.DeadlineExceeded, "busy with a previous call")
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "busy with a previous call")
+	}
+}
+
+// release mark the child at index as not running a call
+func (c *composite[T]) release(index int) {
+	<-c.calls[index]
+}
+
+// callLater call operation on the child at index once its previous call
+// return, within its timeout but without ctx cancellation
+func (c *composite[T]) callLater(ctx context.Context, index int, child T, operation func(context.Context, T) error) {
+	c.calls[index] <- struct{}{}
+	defer c.release(index)
+
+	ctx = context.WithoutCancel(ctx)
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	_ = operation(ctx, child)
+}
+
+// call operation on the child at index, acquired until operation return,
+// and wait for it until its timeout or ctx is done
+func (c *composite[T]) call(
+	ctx context.Context,
+	index int,
+	child T,
+	operation func(context.Context, T) error,
+) error {
+	var (
+		childCtx, cancel = context.WithCancel(ctx)
+		done             = make(chan error, 1)
+		timeout          <-chan time.Time
+	)
+	defer cancel()
+
+	if c.timeout > 0 {
+		childCtx, cancel = context.WithTimeout(childCtx, c.timeout)
+		defer cancel()
+
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	go func() {
+		defer c.release(index)
+
+		done <- operation(childCtx, child)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timeout:
+		return context.DeadlineExceeded
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	}
+}
+
+// forceFlush flush the children that support it, waiting for the ones still
+// running a call
+func (c *composite[T]) forceFlush(ctx context.Context) error {
+	return c.fanOut(ctx, waitBusy, func() func(context.Context, T) error {
+		return func(ctx context.Context, child T) error {
+			if flusher, ok := any(child).(interface{ ForceFlush(context.Context) error }); ok {
+				return flusher.ForceFlush(ctx)
+			}
+
+			return nil
+		}
+	})
+}
+
+// shutdown all children, the ones still running a call once it return
+func (c *composite[T]) shutdown(ctx context.Context) error {
+	return c.fanOut(ctx, deferBusy, func() func(context.Context, T) error {
+		return func(ctx context.Context, child T) error {
+			return child.Shutdown(ctx)
+		}
+	})
+}
@@ -4,8 +4,7 @@ package telemetry
 import (
 	"context"
 	"os"
-	"strings"
-	"sync"
+	"slices"
 
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/exporters/autoexport"
@@ -15,129 +14,102 @@ import (
 	"go.opentelemetry.io/otel/sdk/log"
 )
 
-// OTLP environment variable keys
-const (
-	// otelExporterOTLPLogsProtoEnvKey is the environment variable key for logs protocol
-	otelExporterOTLPLogsProtoEnvKey = "OTEL_EXPORTER_OTLP_LOGS_PROTOCOL"
-	// otelExporterOTLPTracesProtoEnvKey is the environment variable key for traces protocol
-	otelExporterOTLPTracesProtoEnvKey = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
-	// otelExporterOTLPProtoEnvKey is the general OTLP protocol environment variable key
-	otelExporterOTLPProtoEnvKey = "OTEL_EXPORTER_OTLP_PROTOCOL"
-)
-
-func init() {
-	// Register our composite exporter type
-	autoexport.RegisterLogExporter("console+otlp", func(ctx context.Context) (log.Exporter, error) {
-		const onlyTwo = 2
-
-		var (
-			proto     = os.Getenv(otelExporterOTLPLogsProtoEnvKey)
-			err       error
-			composite = &compositeExporter{
-				exporters: make([]log.Exporter, onlyTwo),
-			}
-		)
-
-		if proto == "" {
-			proto = os.Getenv(otelExporterOTLPProtoEnvKey)
-		}
-
-		// Fallback to default, http/protobuf.
-		if proto == "" {
-			proto = "http/protobuf"
-		}
-
-		composite.exporters[0], err = stdoutlog.New()
+// logExporters are the children a compositeLogExporter can be made of
+var logExporters = map[string]func(context.Context) (log.Exporter, error){
+	exporterConsole: func(context.Context) (log.Exporter, error) {
+		exporter, err := stdoutlog.New(stdoutlog.WithWriter(console))
 		if err != nil {
 			return nil, errors.Wrap(err, "stdoutlog.New")
 		}
 
-		switch proto {
-		case "grpc":
-			if composite.exporters[1], err = otlploggrpc.New(ctx); err != nil {
+		return exporter, nil
+	},
+	exporterOTLP: func(ctx context.Context) (log.Exporter, error) {
+		switch otlpProtocol(otelExporterOTLPLogsProtoEnvKey) {
+		case protoGRPC:
+			exporter, err := otlploggrpc.New(ctx)
+			if err != nil {
 				return nil, errors.Wrap(err, "otlploggrpc.New")
 			}
 
-			return composite, nil
-		case "http/protobuf":
-			if composite.exporters[1], err = otlploghttp.New(ctx); err != nil {
+			return exporter, nil
+		case protoHTTPProtobuf:
+			exporter, err := otlploghttp.New(ctx)
+			if err != nil {
 				return nil, errors.Wrap(err, "otlploghttp.New")
 			}
 
-			return composite, nil
+			return exporter, nil
 		default:
-			return nil, errors.New("invalid OTLP protocol - should be one of ['grpc', 'http/protobuf']")
+			return nil, errors.New(invalidProtoMessage)
 		}
-	})
-}
-
-// compositeExporter implements log.Exporter
-type compositeExporter struct {
-	exporters []log.Exporter
-	mu        sync.Mutex
+	},
 }
 
-func wrapMultiErrors(errs []error) error {
-	lenErrs := len(errs)
-
-	if lenErrs == 0 {
-		return nil
-	}
+func init() {
+	// Register our composite exporter type
+	autoexport.RegisterLogExporter("console+otlp", func(ctx context.Context) (log.Exporter, error) {
+		exporter, err := newCompositeLogExporter(ctx, []string{exporterConsole, exporterOTLP})
+		if err != nil {
+			return nil, err
+		}
 
-	errsString := make([]string, lenErrs)
+		return exporter, nil
+	})
+}
 
-	for index, err := range errs {
-		errsString[index] = err.Error()
+// newCompositeLogExporter create a composite of the logExporters named
+func newCompositeLogExporter(ctx context.Context, names []string) (*compositeLogExporter, error) {
+	children, err := newComposite(ctx, otelLogsExporterEnvKey, names, logExporters)
+	if err != nil {
+		return nil, err
 	}
 
-	return errors.Errorf("error(s) occurred: %s", strings.Join(errsString, ","))
+	return &compositeLogExporter{composite: children}, nil
 }
 
-func (c *compositeExporter) Export(ctx context.Context, records []log.Record) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// newLogExporter create the exporter set by OTEL_LOGS_EXPORTER, a composite
+// when it is a list like "console,otlp" or the console
+func newLogExporter(ctx context.Context) (log.Exporter, error) {
+	if !isCompositeExporter(otelLogsExporterEnvKey) {
+		exporter, err := autoexport.NewLogExporter(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "autoexport.NewLogExporter")
+		}
 
-	var errs []error
+		return exporter, nil
+	}
 
-	for _, exp := range c.exporters {
-		if err := exp.Export(ctx, records); err != nil {
-			errs = append(errs, err)
-		}
+	exporter, err := newCompositeLogExporter(ctx, exporterNames(os.Getenv(otelLogsExporterEnvKey)))
+	if err != nil {
+		return nil, err
 	}
 
-	return wrapMultiErrors(errs)
+	return exporter, nil
 }
 
-func (c *compositeExporter) ForceFlush(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	var errs []error
+// compositeLogExporter implements log.Exporter
+type compositeLogExporter struct {
+	*composite[log.Exporter]
+}
 
-	for _, exp := range c.exporters {
-		if f, ok := exp.(interface{ ForceFlush(context.Context) error }); ok {
-			if err := f.ForceFlush(ctx); err != nil {
-				errs = append(errs, err)
-			}
+func (c *compositeLogExporter) Export(ctx context.Context, records []log.Record) error {
+	return c.fanOut(ctx, skipBusy, func() func(context.Context, log.Exporter) error {
+		records := slices.Clone(records)
+		for index := range records {
+			records[index] = records[index].Clone()
 		}
-	}
 
-	return wrapMultiErrors(errs)
+		return func(ctx context.Context, child log.Exporter) error {
+			return child.Export(ctx, records)
+		}
+	})
 }
 
-func (c *compositeExporter) Shutdown(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	var errs []error
-
-	for _, exp := range c.exporters {
-		if s, ok := exp.(interface{ Shutdown(context.Context) error }); ok {
-			if err := s.Shutdown(ctx); err != nil {
-				errs = append(errs, err)
-			}
-		}
-	}
+func (c *compositeLogExporter) ForceFlush(ctx context.Context) error {
+	return c.forceFlush(ctx)
+}
 
-	return wrapMultiErrors(errs)
+func (c *compositeLogExporter) Shutdown(ctx context.Context) error {
+	return c.shutdown(ctx)
 }
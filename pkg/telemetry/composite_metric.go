@@ -0,0 +1,245 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"slices"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/exporters/autoexport"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// metricExporters are the children a compositeMetricExporter can be made of
+var metricExporters = map[string]func(context.Context) (metric.Exporter, error){
+	exporterConsole: func(context.Context) (metric.Exporter, error) {
+		exporter, err := stdoutmetric.New(stdoutmetric.WithWriter(console))
+		if err != nil {
+			return nil, errors.Wrap(err, "stdoutmetric.New")
+		}
+
+		return exporter, nil
+	},
+	exporterOTLP: func(ctx context.Context) (metric.Exporter, error) {
+		switch otlpProtocol(otelExporterOTLPMetricsProtoEnvKey) {
+		case protoGRPC:
+			exporter, err := otlpmetricgrpc.New(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, "otlpmetricgrpc.New")
+			}
+
+			return exporter, nil
+		case protoHTTPProtobuf:
+			exporter, err := otlpmetrichttp.New(ctx)
+			if err != nil {
+				return nil, errors.Wrap(err, "otlpmetrichttp.New")
+			}
+
+			return exporter, nil
+		default:
+			return nil, errors.New(invalidProtoMessage)
+		}
+	},
+}
+
+func init() {
+	// Register our composite exporter type
+	autoexport.RegisterMetricReader("console+otlp", func(ctx context.Context) (metric.Reader, error) {
+		exporter, err := newCompositeMetricExporter(ctx, []string{exporterConsole, exporterOTLP})
+		if err != nil {
+			return nil, err
+		}
+
+		return metric.NewPeriodicReader(exporter), nil
+	})
+}
+
+// newCompositeMetricExporter create a composite of the metricExporters named
+func newCompositeMetricExporter(ctx context.Context, names []string) (*compositeMetricExporter, error) {
+	children, err := newComposite(ctx, otelMetricsExporterEnvKey, names, metricExporters)
+	if err != nil {
+		return nil, err
+	}
+
+	return &compositeMetricExporter{composite: children}, nil
+}
+
+// newMetricReader create the reader set by OTEL_METRICS_EXPORTER, a periodic
+// reader of a composite when it is a list like "console,otlp" or the console
+func newMetricReader(ctx context.Context) (metric.Reader, error) {
+	if !isCompositeExporter(otelMetricsExporterEnvKey) {
+		reader, err := autoexport.NewMetricReader(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "autoexport.NewMetricReader")
+		}
+
+		return reader, nil
+	}
+
+	exporter, err := newCompositeMetricExporter(ctx, exporterNames(os.Getenv(otelMetricsExporterEnvKey)))
+	if err != nil {
+		return nil, err
+	}
+
+	return metric.NewPeriodicReader(exporter), nil
+}
+
+// compositeMetricExporter implements metric.Exporter, with the temporality
+// and aggregation of its first exporter
+type compositeMetricExporter struct {
+	*composite[metric.Exporter]
+}
+
+func (c *compositeMetricExporter) Temporality(kind metric.InstrumentKind) metricdata.Temporality {
+	if len(c.children) == 0 {
+		return metric.DefaultTemporalitySelector(kind)
+	}
+
+	return c.children[0].Temporality(kind)
+}
+
+func (c *compositeMetricExporter) Aggregation(kind metric.InstrumentKind) metric.Aggregation {
+	if len(c.children) == 0 {
+		return metric.DefaultAggregationSelector(kind)
+	}
+
+	return c.children[0].Aggregation(kind)
+}
+
+func (c *compositeMetricExporter) Export(ctx context.Context, metrics *metricdata.ResourceMetrics) error {
+	return c.fanOut(ctx, skipBusy, func() func(context.Context, metric.Exporter) error {
+		metrics := copyResourceMetrics(metrics)
+
+		return func(ctx context.Context, child metric.Exporter) error {
+			return child.Export(ctx, metrics)
+		}
+	})
+}
+
+func (c *compositeMetricExporter) ForceFlush(ctx context.Context) error {
+	return c.forceFlush(ctx)
+}
+
+func (c *compositeMetricExporter) Shutdown(ctx context.Context) error {
+	return c.shutdown(ctx)
+}
+
+// copyResourceMetrics deep copy metrics, which the reader reuse for its next
+// collection once Export return
+func copyResourceMetrics(metrics *metricdata.ResourceMetrics) *metricdata.ResourceMetrics {
+	copied := &metricdata.ResourceMetrics{
+		Resource:     metrics.Resource,
+		ScopeMetrics: slices.Clone(metrics.ScopeMetrics),
+	}
+
+	for scopeIndex := range copied.ScopeMetrics {
+		scope := &copied.ScopeMetrics[scopeIndex]
+		scope.Metrics = slices.Clone(scope.Metrics)
+
+		for index := range scope.Metrics {
+			scope.Metrics[index].Data = copyAggregation(scope.Metrics[index].Data)
+		}
+	}
+
+	return copied
+}
+
+// copyAggregation deep copy the data points of an aggregation
+func copyAggregation(data metricdata.Aggregation) metricdata.Aggregation {
+	switch data := data.(type) {
+	case metricdata.Gauge[int64]:
+		data.DataPoints = copyDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.Gauge[float64]:
+		data.DataPoints = copyDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.Sum[int64]:
+		data.DataPoints = copyDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.Sum[float64]:
+		data.DataPoints = copyDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.Histogram[int64]:
+		data.DataPoints = copyHistogramDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.Histogram[float64]:
+		data.DataPoints = copyHistogramDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.ExponentialHistogram[int64]:
+		data.DataPoints = copyExponentialHistogramDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.ExponentialHistogram[float64]:
+		data.DataPoints = copyExponentialHistogramDataPoints(data.DataPoints)
+
+		return data
+	case metricdata.Summary:
+		data.DataPoints = slices.Clone(data.DataPoints)
+		for index := range data.DataPoints {
+			data.DataPoints[index].QuantileValues = slices.Clone(data.DataPoints[index].QuantileValues)
+		}
+
+		return data
+	default:
+		return data
+	}
+}
+
+func copyDataPoints[N int64 | float64](points []metricdata.DataPoint[N]) []metricdata.DataPoint[N] {
+	points = slices.Clone(points)
+	for index := range points {
+		points[index].Exemplars = copyExemplars(points[index].Exemplars)
+	}
+
+	return points
+}
+
+func copyHistogramDataPoints[N int64 | float64](
+	points []metricdata.HistogramDataPoint[N],
+) []metricdata.HistogramDataPoint[N] {
+	points = slices.Clone(points)
+	for index := range points {
+		point := &points[index]
+		point.Bounds = slices.Clone(point.Bounds)
+		point.BucketCounts = slices.Clone(point.BucketCounts)
+		point.Exemplars = copyExemplars(point.Exemplars)
+	}
+
+	return points
+}
+
+func copyExponentialHistogramDataPoints[N int64 | float64](
+	points []metricdata.ExponentialHistogramDataPoint[N],
+) []metricdata.ExponentialHistogramDataPoint[N] {
+	points = slices.Clone(points)
+	for index := range points {
+		point := &points[index]
+		point.PositiveBucket.Counts = slices.Clone(point.PositiveBucket.Counts)
+		point.NegativeBucket.Counts = slices.Clone(point.NegativeBucket.Counts)
+		point.Exemplars = copyExemplars(point.Exemplars)
+	}
+
+	return points
+}
+
+func copyExemplars[N int64 | float64](exemplars []metricdata.Exemplar[N]) []metricdata.Exemplar[N] {
+	exemplars = slices.Clone(exemplars)
+	for index := range exemplars {
+		exemplar := &exemplars[index]
+		exemplar.FilteredAttributes = slices.Clone(exemplar.FilteredAttributes)
+		exemplar.SpanID = slices.Clone(exemplar.SpanID)
+		exemplar.TraceID = slices.Clone(exemplar.TraceID)
+	}
+
+	return exemplars
+}
@@ -0,0 +1,358 @@
+package telemetry
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeExporter implements the exporters of all signals, counting calls
+type fakeExporter struct {
+	err      error
+	exports  int
+	flushes  int
+	shutdown int
+}
+
+func (exporter *fakeExporter) export() error {
+	exporter.exports++
+
+	return exporter.err
+}
+
+func (exporter *fakeExporter) ExportSpans(context.Context, []trace.ReadOnlySpan) error {
+	return exporter.export()
+}
+
+func (exporter *fakeExporter) Export(context.Context, []log.Record) error {
+	return exporter.export()
+}
+
+func (exporter *fakeExporter) ForceFlush(context.Context) error {
+	exporter.flushes++
+
+	return exporter.err
+}
+
+func (exporter *fakeExporter) Shutdown(context.Context) error {
+	exporter.shutdown++
+
+	return exporter.err
+}
+
+// slowExporter implements trace.SpanExporter, blocking each export until its
+// context is done
+type slowExporter struct {
+	fakeExporter
+}
+
+func (exporter *slowExporter) ExportSpans(ctx context.Context, _ []trace.ReadOnlySpan) error {
+	exporter.exports++
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+// hungExporter implements trace.SpanExporter, blocking each export until
+// release is closed whatever its context
+type hungExporter struct {
+	fakeExporter
+
+	release chan struct{}
+	// exported receive the spans once released, when not nil
+	exported chan []trace.ReadOnlySpan
+	// stopped is closed on Shutdown, when not nil
+	stopped chan struct{}
+}
+
+func (exporter *hungExporter) ExportSpans(_ context.Context, spans []trace.ReadOnlySpan) error {
+	<-exporter.release
+
+	if exporter.exported != nil {
+		exporter.exported <- spans
+	}
+
+	return nil
+}
+
+func (exporter *hungExporter) Shutdown(context.Context) error {
+	if exporter.stopped != nil {
+		close(exporter.stopped)
+	}
+
+	return nil
+}
+
+// newTestComposite create a composite of children named child0, child1...
+func newTestComposite[T shutdowner](timeout time.Duration, children ...T) *composite[T] {
+	names := make([]string, len(children))
+	for index := range children {
+		names[index] = "child" + strconv.Itoa(index)
+	}
+
+	return newCompositeOf(names, children, timeout)
+}
+
+// fakeMetricExporter implements metric.Exporter
+type fakeMetricExporter struct {
+	fakeExporter
+}
+
+func (exporter *fakeMetricExporter) Temporality(metric.InstrumentKind) metricdata.Temporality {
+	return metricdata.DeltaTemporality
+}
+
+func (exporter *fakeMetricExporter) Aggregation(kind metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(kind)
+}
+
+func (exporter *fakeMetricExporter) Export(context.Context, *metricdata.ResourceMetrics) error {
+	return exporter.export()
+}
+
+func TestExporterNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "empty", value: "", want: nil},
+		{name: "single", value: "otlp", want: []string{"otlp"}},
+		{name: "list", value: "console,otlp,file", want: []string{"console", "otlp", "file"}},
+		{name: "spaces and duplicates", value: " console , otlp,console,", want: []string{"console", "otlp"}},
+		{name: "none", value: "none,console", want: []string{"console"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exporterNames(tt.value))
+		})
+	}
+}
+
+func TestNewExportersFromList(t *testing.T) {
+	t.Setenv(otelTracesExporterEnvKey, "console,otlp")
+	t.Setenv(otelLogsExporterEnvKey, "console,otlp")
+	t.Setenv(otelMetricsExporterEnvKey, "console,otlp")
+
+	spanExporter, err := newSpanExporter(t.Context())
+	require.NoError(t, err)
+	require.IsType(t, &compositeTraceExporter{}, spanExporter)
+	assert.Equal(t, []string{"console", "otlp"}, spanExporter.(*compositeTraceExporter).names)
+	assert.Equal(t, defaultChildTimeout, spanExporter.(*compositeTraceExporter).timeout)
+	assert.NoError(t, spanExporter.Shutdown(t.Context()))
+
+	logExporter, err := newLogExporter(t.Context())
+	require.NoError(t, err)
+	require.IsType(t, &compositeLogExporter{}, logExporter)
+	assert.Len(t, logExporter.(*compositeLogExporter).children, 2)
+	assert.NoError(t, logExporter.Shutdown(t.Context()))
+
+	metricReader, err := newMetricReader(t.Context())
+	require.NoError(t, err)
+	assert.IsType(t, &metric.PeriodicReader{}, metricReader)
+	assert.NoError(t, metricReader.Shutdown(t.Context()))
+}
+
+func TestNewExportersUnknown(t *testing.T) {
+	t.Setenv(otelTracesExporterEnvKey, "console,unknown")
+
+	_, err := newSpanExporter(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown exporter "unknown" in OTEL_TRACES_EXPORTER`)
+}
+
+func TestNewExportersInvalidProtocol(t *testing.T) {
+	t.Setenv(otelMetricsExporterEnvKey, "console,otlp")
+	t.Setenv(otelExporterOTLPMetricsProtoEnvKey, "invalid")
+
+	_, err := newMetricReader(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid OTLP protocol")
+}
+
+func TestCompositeExporters(t *testing.T) {
+	var (
+		succeeding = &fakeExporter{}
+		failing    = &fakeExporter{err: errors.New("failure")}
+		traces     = &compositeTraceExporter{newTestComposite[trace.SpanExporter](time.Second, succeeding, failing)}
+		logs       = &compositeLogExporter{newTestComposite[log.Exporter](time.Second, succeeding, failing)}
+	)
+
+	err := traces.ExportSpans(t.Context(), nil)
+	require.Error(t, err)
+	assert.Equal(t, "error(s) occurred: exporter child1: failure", err.Error())
+
+	require.ErrorContains(t, logs.Export(t.Context(), nil), "exporter child1: failure")
+	require.ErrorContains(t, traces.ForceFlush(t.Context()), "exporter child1: failure")
+	require.ErrorContains(t, logs.Shutdown(t.Context()), "exporter child1: failure")
+
+	for _, exporter := range []*fakeExporter{succeeding, failing} {
+		assert.Equal(t, 2, exporter.exports)
+		assert.Equal(t, 1, exporter.flushes)
+		assert.Equal(t, 1, exporter.shutdown)
+	}
+}
+
+func TestCompositeMetricExporter(t *testing.T) {
+	var (
+		first     = &fakeMetricExporter{}
+		second    = &fakeMetricExporter{}
+		composite = &compositeMetricExporter{newTestComposite[metric.Exporter](time.Second, first, second)}
+	)
+
+	assert.Equal(t, metricdata.DeltaTemporality, composite.Temporality(metric.InstrumentKindCounter))
+	assert.Equal(t, metricdata.CumulativeTemporality, (&compositeMetricExporter{newTestComposite[metric.Exporter](0)}).Temporality(metric.InstrumentKindCounter))
+
+	require.NoError(t, composite.Export(t.Context(), &metricdata.ResourceMetrics{}))
+	require.NoError(t, composite.ForceFlush(t.Context()))
+	require.NoError(t, composite.Shutdown(t.Context()))
+
+	for _, exporter := range []*fakeMetricExporter{first, second} {
+		assert.Equal(t, 1, exporter.exports)
+		assert.Equal(t, 1, exporter.flushes)
+		assert.Equal(t, 1, exporter.shutdown)
+	}
+}
+
+func TestCompositeSlowChild(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+
+	var (
+		fast   = &fakeExporter{}
+		slow1  = &slowExporter{}
+		slow2  = &slowExporter{}
+		traces = &compositeTraceExporter{
+			newTestComposite[trace.SpanExporter](timeout, slow1, fast, slow2),
+		}
+	)
+
+	start := time.Now()
+	err := traces.ExportSpans(t.Context(), nil)
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exporter child0: context deadline exceeded")
+	assert.Contains(t, err.Error(), "exporter child2: context deadline exceeded")
+	assert.NotContains(t, err.Error(), "child1")
+
+	assert.Equal(t, 1, fast.exports)
+	assert.Equal(t, 1, slow1.exports)
+	assert.Equal(t, 1, slow2.exports)
+
+	// slow children run concurrently, each one within its own timeout
+	assert.GreaterOrEqual(t, elapsed, timeout)
+	assert.Less(t, elapsed, 2*timeout)
+}
+
+func TestCompositeHungChild(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	var (
+		fast   = &fakeExporter{}
+		hung   = &hungExporter{release: make(chan struct{})}
+		traces = &compositeTraceExporter{newTestComposite[trace.SpanExporter](timeout, hung, fast)}
+	)
+
+	start := time.Now()
+	err := traces.ExportSpans(t.Context(), nil)
+	require.ErrorContains(t, err, "exporter child0: context deadline exceeded")
+	assert.Less(t, time.Since(start), 10*timeout)
+
+	// the hung child is skipped, the others are not blocked
+	start = time.Now()
+	err = traces.ExportSpans(t.Context(), nil)
+	require.ErrorContains(t, err, "exporter child0: busy with a previous call")
+	assert.Less(t, time.Since(start), timeout)
+	assert.Equal(t, 2, fast.exports)
+
+	// the child is called again once its call returned
+	close(hung.release)
+
+	assert.Eventually(t, func() bool {
+		return traces.ExportSpans(t.Context(), nil) == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCompositeHungChildData(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	var (
+		hung = &hungExporter{
+			release:  make(chan struct{}),
+			exported: make(chan []trace.ReadOnlySpan, 1),
+			stopped:  make(chan struct{}),
+		}
+		traces = &compositeTraceExporter{newTestComposite[trace.SpanExporter](timeout, hung)}
+		spans  = []trace.ReadOnlySpan{tracetest.SpanStub{Name: "reused"}.Snapshot()}
+	)
+
+	require.ErrorContains(t, traces.ExportSpans(t.Context(), spans), "context deadline exceeded")
+
+	// the SDK reuse the spans once the export returned
+	clear(spans)
+
+	// a busy child is shut down once its call return
+	ctx, cancel := context.WithTimeout(t.Context(), timeout)
+	defer cancel()
+
+	require.ErrorContains(t, traces.Shutdown(ctx), "exporter child0: busy with a previous call")
+
+	close(hung.release)
+
+	exported := <-hung.exported
+	require.Len(t, exported, 1)
+	require.NotNil(t, exported[0])
+	assert.Equal(t, "reused", exported[0].Name())
+
+	select {
+	case <-hung.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the child was not shut down")
+	}
+}
+
+func TestCopyResourceMetrics(t *testing.T) {
+	var (
+		points  = []metricdata.HistogramDataPoint[int64]{{Count: 1, BucketCounts: []uint64{1, 0}}}
+		metrics = &metricdata.ResourceMetrics{
+			ScopeMetrics: []metricdata.ScopeMetrics{{
+				Metrics: []metricdata.Metrics{
+					{Name: "histogram", Data: metricdata.Histogram[int64]{DataPoints: points}},
+					{Name: "sum", Data: metricdata.Sum[float64]{DataPoints: []metricdata.DataPoint[float64]{{Value: 1}}}},
+				},
+			}},
+		}
+		copied = copyResourceMetrics(metrics)
+	)
+
+	// the reader reuse the metrics for its next collection
+	points[0].BucketCounts[0] = 2
+	metrics.ScopeMetrics[0].Metrics[1].Data.(metricdata.Sum[float64]).DataPoints[0].Value = 2
+	metrics.ScopeMetrics[0].Metrics[0].Name = "reused"
+
+	assert.Equal(t, &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{
+				{
+					Name: "histogram",
+					Data: metricdata.Histogram[int64]{
+						DataPoints: []metricdata.HistogramDataPoint[int64]{{Count: 1, BucketCounts: []uint64{1, 0}}},
+					},
+				},
+				{Name: "sum", Data: metricdata.Sum[float64]{DataPoints: []metricdata.DataPoint[float64]{{Value: 1}}}},
+			},
+		}},
+	}, copied)
+}
@@ -4,7 +4,7 @@ package telemetry
 import (
 	"context"
 	"os"
-	"sync"
+	"slices"
 
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/exporters/autoexport"
@@ -14,99 +14,99 @@ import (
 	"go.opentelemetry.io/otel/sdk/trace"
 )
 
-func init() {
-	// Register our composite exporter type
-	autoexport.RegisterSpanExporter("console+otlp", func(ctx context.Context) (trace.SpanExporter, error) {
-		const onlyTwo = 2
-
-		var (
-			proto     = os.Getenv(otelExporterOTLPTracesProtoEnvKey)
-			err       error
-			composite = &compositeTraceExporter{
-				exporters: make([]trace.SpanExporter, onlyTwo),
-			}
-		)
-
-		if proto == "" {
-			proto = os.Getenv(otelExporterOTLPProtoEnvKey)
-		}
-
-		// Fallback to default, http/protobuf.
-		if proto == "" {
-			proto = "http/protobuf"
-		}
-
-		composite.exporters[0], err = stdouttrace.New()
+// spanExporters are the children a compositeTraceExporter can be made of
+var spanExporters = map[string]func(context.Context) (trace.SpanExporter, error){
+	exporterConsole: func(context.Context) (trace.SpanExporter, error) {
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(console))
 		if err != nil {
 			return nil, errors.Wrap(err, "stdouttrace.New")
 		}
 
-		switch proto {
-		case "grpc":
-			if composite.exporters[1], err = otlptracegrpc.New(ctx); err != nil {
+		return exporter, nil
+	},
+	exporterOTLP: func(ctx context.Context) (trace.SpanExporter, error) {
+		switch otlpProtocol(otelExporterOTLPTracesProtoEnvKey) {
+		case protoGRPC:
+			exporter, err := otlptracegrpc.New(ctx)
+			if err != nil {
 				return nil, errors.Wrap(err, "otlptracegrpc.New")
 			}
-			return composite, nil
-		case "http/protobuf":
-			if composite.exporters[1], err = otlptracehttp.New(ctx); err != nil {
+
+			return exporter, nil
+		case protoHTTPProtobuf:
+			exporter, err := otlptracehttp.New(ctx)
+			if err != nil {
 				return nil, errors.Wrap(err, "otlptracehttp.New")
 			}
-			return composite, nil
+
+			return exporter, nil
 		default:
-			return nil, errors.New("invalid OTLP protocol - should be one of ['grpc', 'http/protobuf']")
+			return nil, errors.New(invalidProtoMessage)
 		}
-	})
-}
-
-// compositeTraceExporter implements trace.SpanExporter
-type compositeTraceExporter struct {
-	exporters []trace.SpanExporter
-	mu        sync.Mutex
+	},
 }
 
-func (c *compositeTraceExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func init() {
+	// Register our composite exporter type
+	autoexport.RegisterSpanExporter("console+otlp", func(ctx context.Context) (trace.SpanExporter, error) {
+		exporter, err := newCompositeTraceExporter(ctx, []string{exporterConsole, exporterOTLP})
+		if err != nil {
+			return nil, err
+		}
 
-	var errs []error
+		return exporter, nil
+	})
+}
 
-	for _, exp := range c.exporters {
-		if err := exp.ExportSpans(ctx, spans); err != nil {
-			errs = append(errs, err)
-		}
+// newCompositeTraceExporter create a composite of the spanExporters named
+func newCompositeTraceExporter(ctx context.Context, names []string) (*compositeTraceExporter, error) {
+	children, err := newComposite(ctx, otelTracesExporterEnvKey, names, spanExporters)
+	if err != nil {
+		return nil, err
 	}
 
-	return wrapMultiErrors(errs)
+	return &compositeTraceExporter{composite: children}, nil
 }
 
-func (c *compositeTraceExporter) Shutdown(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// newSpanExporter create the exporter set by OTEL_TRACES_EXPORTER, a
+// composite when it is a list like "console,otlp" or the console
+func newSpanExporter(ctx context.Context) (trace.SpanExporter, error) {
+	if !isCompositeExporter(otelTracesExporterEnvKey) {
+		exporter, err := autoexport.NewSpanExporter(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "autoexport.NewSpanExporter")
+		}
 
-	var errs []error
+		return exporter, nil
+	}
 
-	for _, exp := range c.exporters {
-		if err := exp.Shutdown(ctx); err != nil {
-			errs = append(errs, err)
-		}
+	exporter, err := newCompositeTraceExporter(ctx, exporterNames(os.Getenv(otelTracesExporterEnvKey)))
+	if err != nil {
+		return nil, err
 	}
 
-	return wrapMultiErrors(errs)
+	return exporter, nil
 }
 
-func (c *compositeTraceExporter) ForceFlush(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// compositeTraceExporter implements trace.SpanExporter
+type compositeTraceExporter struct {
+	*composite[trace.SpanExporter]
+}
 
-	var errs []error
+func (c *compositeTraceExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	return c.fanOut(ctx, skipBusy, func() func(context.Context, trace.SpanExporter) error {
+		spans := slices.Clone(spans)
 
-	for _, exp := range c.exporters {
-		if f, ok := exp.(interface{ ForceFlush(context.Context) error }); ok {
-			if err := f.ForceFlush(ctx); err != nil {
-				errs = append(errs, err)
-			}
+		return func(ctx context.Context, child trace.SpanExporter) error {
+			return child.ExportSpans(ctx, spans)
 		}
-	}
+	})
+}
 
-	return wrapMultiErrors(errs)
+func (c *compositeTraceExporter) ForceFlush(ctx context.Context) error {
+	return c.forceFlush(ctx)
+}
+
+func (c *compositeTraceExporter) Shutdown(ctx context.Context) error {
+	return c.shutdown(ctx)
 }
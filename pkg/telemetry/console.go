@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// consoleWriter is the output of the console exporters, stdout unless set to
+// another writer or protected, each write being done under a lock so lines of
+// different signals do not interleave
+type consoleWriter struct {
+	mu     sync.Mutex
+	writer io.Writer
+	// stdoutProtected redirect writes to stdout to stderr
+	stdoutProtected bool
+}
+
+// console is the output shared by the console exporters of all signals
+var console = &consoleWriter{}
+
+// output return the writer written to, resolving stdout and stderr at each
+// write so they can be replaced
+func (c *consoleWriter) output() io.Writer {
+	writer := c.writer
+	if writer == nil || writer == io.Writer(os.Stdout) {
+		writer = os.Stdout
+
+		if c.stdoutProtected {
+			writer = os.Stderr
+		}
+	}
+
+	return writer
+}
+
+func (c *consoleWriter) Write(data []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.output().Write(data)
+}
+
+// SetConsoleOutput make the console exporters write to writer (like a file)
+// instead of stdout, a nil writer restoring stdout
+func SetConsoleOutput(writer io.Writer) {
+	console.mu.Lock()
+	defer console.mu.Unlock()
+
+	console.writer = writer
+}
+
+// ProtectStdout make the console exporters write to stderr instead of stdout,
+// which is kept for a protocol like the stdio transport of MCP, until the
+// returned restore func is called. An output set by SetConsoleOutput other
+// than stdout is left as is
+func ProtectStdout() (restore func()) {
+	console.mu.Lock()
+	defer console.mu.Unlock()
+
+	previous := console.stdoutProtected
+	console.stdoutProtected = true
+
+	return func() {
+		console.mu.Lock()
+		defer console.mu.Unlock()
+
+		console.stdoutProtected = previous
+	}
+}
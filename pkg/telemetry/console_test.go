@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestConsoleWriterOutput(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	tests := []struct {
+		name            string
+		writer          io.Writer
+		stdoutProtected bool
+		want            io.Writer
+	}{
+		{name: "default", want: os.Stdout},
+		{name: "protected", stdoutProtected: true, want: os.Stderr},
+		{name: "writer", writer: buffer, want: buffer},
+		{name: "protected writer", writer: buffer, stdoutProtected: true, want: buffer},
+		{name: "protected stdout", writer: os.Stdout, stdoutProtected: true, want: os.Stderr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &consoleWriter{writer: tt.writer, stdoutProtected: tt.stdoutProtected}
+
+			assert.Equal(t, tt.want, writer.output())
+		})
+	}
+}
+
+func TestProtectStdout(t *testing.T) {
+	restoreOuter := ProtectStdout()
+	restoreInner := ProtectStdout()
+
+	assert.Equal(t, os.Stderr, console.output())
+
+	restoreInner()
+	assert.Equal(t, os.Stderr, console.output())
+
+	restoreOuter()
+	assert.Equal(t, os.Stdout, console.output())
+}
+
+func TestConsoleExporterOutput(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	SetConsoleOutput(buffer)
+	t.Cleanup(func() {
+		SetConsoleOutput(nil)
+	})
+
+	t.Setenv(otelTracesExporterEnvKey, exporterConsole)
+
+	exporter, err := newSpanExporter(t.Context())
+	require.NoError(t, err)
+	require.IsType(t, &compositeTraceExporter{}, exporter)
+
+	provider := trace.NewTracerProvider(trace.WithSyncer(exporter))
+	_, span := provider.Tracer("test").Start(t.Context(), "console-span")
+	span.End()
+
+	require.NoError(t, provider.Shutdown(t.Context()))
+	assert.Contains(t, buffer.String(), `"Name":"console-span"`)
+}
@@ -0,0 +1,157 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/contrib/exporters/autoexport"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/protobuf/proto"
+)
+
+// exporterFile is the name of the file exporters
+const exporterFile = "file"
+
+func init() {
+	// Register our file exporter type, alone or in a list
+	spanExporters[exporterFile] = func(context.Context) (trace.SpanExporter, error) {
+		return newFileSpanExporter()
+	}
+	logExporters[exporterFile] = func(context.Context) (log.Exporter, error) {
+		return newFileLogExporter()
+	}
+	metricExporters[exporterFile] = func(context.Context) (metric.Exporter, error) {
+		return newFileMetricExporter()
+	}
+
+	autoexport.RegisterSpanExporter(exporterFile, spanExporters[exporterFile])
+	autoexport.RegisterLogExporter(exporterFile, logExporters[exporterFile])
+	autoexport.RegisterMetricReader(exporterFile, func(ctx context.Context) (metric.Reader, error) {
+		exporter, err := metricExporters[exporterFile](ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return metric.NewPeriodicReader(exporter), nil
+	})
+}
+
+// newFileWriter open the rotating file of a signal configured by the
+// environment
+func newFileWriter(pathEnvKey, defaultPath string) (*rotatingFile, error) {
+	config, err := rotatingFileConfigFromEnv(pathEnvKey, defaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRotatingFile(config)
+}
+
+// writeJSONLine write data as one OTLP-JSON line
+func writeJSONLine(writer *rotatingFile, data proto.Message) error {
+	line, err := marshalOTLPJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return writer.WriteLine(line)
+}
+
+// fileSpanExporter implements trace.SpanExporter, writing each batch of spans
+// as a line of OTLP-JSON
+type fileSpanExporter struct {
+	writer *rotatingFile
+}
+
+func newFileSpanExporter() (trace.SpanExporter, error) {
+	writer, err := newFileWriter(otelExporterFileTracesPathEnvKey, defaultFileTracesName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileSpanExporter{writer: writer}, nil
+}
+
+func (exporter *fileSpanExporter) ExportSpans(_ context.Context, spans []trace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	return writeJSONLine(exporter.writer, otlpTracesDataOf(spans))
+}
+
+func (exporter *fileSpanExporter) Shutdown(context.Context) error {
+	return exporter.writer.Close()
+}
+
+// fileLogExporter implements log.Exporter, writing each batch of records as a
+// line of OTLP-JSON
+type fileLogExporter struct {
+	writer *rotatingFile
+}
+
+func newFileLogExporter() (log.Exporter, error) {
+	writer, err := newFileWriter(otelExporterFileLogsPathEnvKey, defaultFileLogsName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileLogExporter{writer: writer}, nil
+}
+
+func (exporter *fileLogExporter) Export(_ context.Context, records []log.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return writeJSONLine(exporter.writer, otlpLogsDataOf(records))
+}
+
+func (exporter *fileLogExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (exporter *fileLogExporter) Shutdown(context.Context) error {
+	return exporter.writer.Close()
+}
+
+// fileMetricExporter implements metric.Exporter, writing each collection as a
+// line of OTLP-JSON with the default temporality and aggregation
+type fileMetricExporter struct {
+	writer *rotatingFile
+}
+
+func newFileMetricExporter() (metric.Exporter, error) {
+	writer, err := newFileWriter(otelExporterFileMetricsPathEnvKey, defaultFileMetricsName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileMetricExporter{writer: writer}, nil
+}
+
+func (exporter *fileMetricExporter) Temporality(kind metric.InstrumentKind) metricdata.Temporality {
+	return metric.DefaultTemporalitySelector(kind)
+}
+
+func (exporter *fileMetricExporter) Aggregation(kind metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(kind)
+}
+
+func (exporter *fileMetricExporter) Export(_ context.Context, metrics *metricdata.ResourceMetrics) error {
+	if len(metrics.ScopeMetrics) == 0 {
+		return nil
+	}
+
+	return writeJSONLine(exporter.writer, otlpMetricsDataOf(metrics))
+}
+
+func (exporter *fileMetricExporter) ForceFlush(context.Context) error {
+	return nil
+}
+
+func (exporter *fileMetricExporter) Shutdown(context.Context) error {
+	return exporter.writer.Close()
+}
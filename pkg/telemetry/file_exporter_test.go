@@ -0,0 +1,185 @@
+package telemetry
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// hexToBase64 convert the OTLP-JSON encoding of the IDs to the protobuf JSON
+// encoding of bytes
+func hexToBase64(encoded string) (string, error) {
+	decoded, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	return base64.StdEncoding.EncodeToString(decoded), nil
+}
+
+// readOTLPJSONLines decode each OTLP-JSON line of the file at path
+func readOTLPJSONLines[T any, M interface {
+	*T
+	proto.Message
+}](t *testing.T, path string) []M {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, file.Close())
+	}()
+
+	var lines []M
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var decoded any
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &decoded))
+		require.NoError(t, convertOTLPIDs(decoded, hexToBase64))
+
+		encoded, err := json.Marshal(decoded)
+		require.NoError(t, err)
+
+		line := M(new(T))
+		require.NoError(t, protojson.Unmarshal(encoded, line))
+
+		lines = append(lines, line)
+	}
+
+	require.NoError(t, scanner.Err())
+
+	return lines
+}
+
+func TestFileExporters(t *testing.T) {
+	var (
+		directory   = t.TempDir()
+		tracesPath  = filepath.Join(directory, "traces.jsonl")
+		logsPath    = filepath.Join(directory, "logs.jsonl")
+		metricsPath = filepath.Join(directory, "metrics.jsonl")
+	)
+
+	t.Setenv(otelTracesExporterEnvKey, exporterFile)
+	t.Setenv(otelLogsExporterEnvKey, exporterFile)
+	t.Setenv(otelMetricsExporterEnvKey, exporterFile)
+	t.Setenv(otelExporterFileTracesPathEnvKey, tracesPath)
+	t.Setenv(otelExporterFileLogsPathEnvKey, logsPath)
+	t.Setenv(otelExporterFileMetricsPathEnvKey, metricsPath)
+
+	tel, err := InitTelemetry(t.Context(), "test-service", "1.0.0", WithoutGlobals())
+	require.NoError(t, err)
+
+	ctx, span := tel.TracerProvider.Tracer("test").Start(
+		t.Context(),
+		"test-span",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.Int64("test.count", 42)),
+	)
+	span.SetStatus(codes.Error, "failure")
+	tel.Logger.InfoContext(ctx, "message", "key", "value")
+	span.End()
+
+	counter, err := tel.MeterProvider.Meter("test").Int64Counter("test.counter")
+	require.NoError(t, err)
+	counter.Add(t.Context(), 3)
+
+	require.NoError(t, tel.Shutdown(t.Context()))
+
+	traces := readOTLPJSONLines[tracepb.TracesData](t, tracesPath)
+	require.Len(t, traces, 1)
+	require.Len(t, traces[0].ResourceSpans, 1)
+	require.Len(t, traces[0].ResourceSpans[0].ScopeSpans, 1)
+	require.Len(t, traces[0].ResourceSpans[0].ScopeSpans[0].Spans, 1)
+
+	exportedSpan := traces[0].ResourceSpans[0].ScopeSpans[0].Spans[0]
+	traceID, spanID := span.SpanContext().TraceID(), span.SpanContext().SpanID()
+	assert.Equal(t, traceID[:], exportedSpan.GetTraceId())
+	assert.Equal(t, spanID[:], exportedSpan.GetSpanId())
+	assert.Equal(t, "test-span", exportedSpan.GetName())
+
+	// the IDs are hex encoded, as OTLP-JSON require
+	raw, err := os.ReadFile(tracesPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"traceId":"`+traceID.String()+`"`)
+
+	assert.Equal(t, tracepb.Span_SPAN_KIND_SERVER, exportedSpan.GetKind())
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, exportedSpan.GetStatus().GetCode())
+	assert.Equal(t, "failure", exportedSpan.GetStatus().GetMessage())
+	require.Len(t, exportedSpan.GetAttributes(), 1)
+	assert.Equal(t, "test.count", exportedSpan.GetAttributes()[0].GetKey())
+	assert.Equal(t, int64(42), exportedSpan.GetAttributes()[0].GetValue().GetIntValue())
+	assert.Equal(t, "test", traces[0].ResourceSpans[0].ScopeSpans[0].Scope.Name)
+
+	logs := readOTLPJSONLines[logspb.LogsData](t, logsPath)
+	require.Len(t, logs, 1)
+	require.Len(t, logs[0].ResourceLogs, 1)
+	require.Len(t, logs[0].ResourceLogs[0].ScopeLogs, 1)
+	require.Len(t, logs[0].ResourceLogs[0].ScopeLogs[0].LogRecords, 1)
+
+	record := logs[0].ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, "message", record.GetBody().GetStringValue())
+	assert.Equal(t, traceID[:], record.GetTraceId())
+	assert.Equal(t, spanID[:], record.GetSpanId())
+	assert.Equal(t, logspb.SeverityNumber(log.SeverityInfo), record.GetSeverityNumber())
+
+	metrics := readOTLPJSONLines[metricspb.MetricsData](t, metricsPath)
+	require.NotEmpty(t, metrics)
+	require.Len(t, metrics[0].ResourceMetrics, 1)
+	require.Len(t, metrics[0].ResourceMetrics[0].ScopeMetrics, 1)
+	require.Len(t, metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics, 1)
+
+	exportedMetric := metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "test.counter", exportedMetric.GetName())
+	require.NotNil(t, exportedMetric.GetSum())
+	assert.Equal(t,
+		metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		exportedMetric.GetSum().GetAggregationTemporality(),
+	)
+	assert.True(t, exportedMetric.GetSum().GetIsMonotonic())
+	require.Len(t, exportedMetric.GetSum().GetDataPoints(), 1)
+	assert.Equal(t, int64(3), exportedMetric.GetSum().GetDataPoints()[0].GetAsInt())
+}
+
+func TestFileExporterInList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	t.Setenv(otelTracesExporterEnvKey, "none,file")
+	t.Setenv(otelExporterFileTracesPathEnvKey, path)
+
+	exporter, err := newSpanExporter(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, exporter.Shutdown(t.Context()))
+
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+}
+
+func TestFileExporterInvalidConfiguration(t *testing.T) {
+	t.Setenv(otelLogsExporterEnvKey, exporterFile)
+	t.Setenv(otelExporterFileDirectoryEnvKey, t.TempDir())
+	t.Setenv(otelExporterFileMaxSizeEnvKey, "large")
+
+	_, err := newLogExporter(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), otelExporterFileMaxSizeEnvKey)
+}
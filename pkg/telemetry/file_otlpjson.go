@@ -0,0 +1,614 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// The OTLP-JSON encoding of the OpenTelemetry protocol, as written by the
+// file exporter: the protobuf JSON mapping with enums as numbers, except for
+// the trace and span IDs which are hex encoded
+
+// otlpTraceFlagsMask keep the W3C trace flags in the flags field
+const otlpTraceFlagsMask uint32 = 0xff
+
+// marshalOTLPJSON encode message in OTLP-JSON
+func marshalOTLPJSON(message proto.Message) ([]byte, error) {
+	encoded, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "protojson.Marshal")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var decoded any
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil, errors.Wrap(err, "json.Decode")
+	}
+
+	if err = convertOTLPIDs(decoded, base64ToHex); err != nil {
+		return nil, err
+	}
+
+	encoded, err = json.Marshal(decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal")
+	}
+
+	return encoded, nil
+}
+
+// convertOTLPIDs replace the trace and span IDs of a decoded JSON value by
+// their conversion
+func convertOTLPIDs(value any, convert func(string) (string, error)) error {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if id, isString := item.(string); isString && isOTLPIDField(key) {
+				converted, err := convert(id)
+				if err != nil {
+					return errors.Wrapf(err, "invalid %s %q", key, id)
+				}
+
+				value[key] = converted
+			} else if err := convertOTLPIDs(item, convert); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if err := convertOTLPIDs(item, convert); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isOTLPIDField tell whether the field key is a trace or span ID
+func isOTLPIDField(key string) bool {
+	return key == "traceId" || key == "spanId" || key == "parentSpanId"
+}
+
+// base64ToHex convert the protobuf JSON encoding of bytes to hex
+func base64ToHex(encoded string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(err, "base64.DecodeString")
+	}
+
+	return hex.EncodeToString(decoded), nil
+}
+
+// otlpTime encode a time as nanoseconds since epoch, unset for the zero time
+func otlpTime(value time.Time) uint64 {
+	if value.IsZero() {
+		return 0
+	}
+
+	return uint64(value.UnixNano()) //nolint:gosec
+}
+
+func otlpArray(values []*commonpb.AnyValue) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{
+		ArrayValue: &commonpb.ArrayValue{Values: values},
+	}}
+}
+
+func otlpAttributeValue(value attribute.Value) *commonpb.AnyValue {
+	var values []*commonpb.AnyValue
+
+	switch value.Type() {
+	case attribute.BOOL:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: value.AsBool()}}
+	case attribute.INT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value.AsInt64()}}
+	case attribute.FLOAT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: value.AsFloat64()}}
+	case attribute.BOOLSLICE:
+		for _, item := range value.AsBoolSlice() {
+			values = append(values, otlpAttributeValue(attribute.BoolValue(item)))
+		}
+	case attribute.INT64SLICE:
+		for _, item := range value.AsInt64Slice() {
+			values = append(values, otlpAttributeValue(attribute.Int64Value(item)))
+		}
+	case attribute.FLOAT64SLICE:
+		for _, item := range value.AsFloat64Slice() {
+			values = append(values, otlpAttributeValue(attribute.Float64Value(item)))
+		}
+	case attribute.STRINGSLICE:
+		for _, item := range value.AsStringSlice() {
+			values = append(values, otlpAttributeValue(attribute.StringValue(item)))
+		}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.Emit()}}
+	}
+
+	return otlpArray(values)
+}
+
+func otlpAttributes(attributes []attribute.KeyValue) []*commonpb.KeyValue {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	encoded := make([]*commonpb.KeyValue, len(attributes))
+	for index, attr := range attributes {
+		encoded[index] = &commonpb.KeyValue{Key: string(attr.Key), Value: otlpAttributeValue(attr.Value)}
+	}
+
+	return encoded
+}
+
+func otlpLogValue(value log.Value) *commonpb.AnyValue {
+	switch value.Kind() {
+	case log.KindBool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: value.AsBool()}}
+	case log.KindInt64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value.AsInt64()}}
+	case log.KindFloat64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: value.AsFloat64()}}
+	case log.KindBytes:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: value.AsBytes()}}
+	case log.KindSlice:
+		var values []*commonpb.AnyValue
+		for _, item := range value.AsSlice() {
+			values = append(values, otlpLogValue(item))
+		}
+
+		return otlpArray(values)
+	case log.KindMap:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{
+			KvlistValue: &commonpb.KeyValueList{Values: otlpLogKeyValues(value.AsMap())},
+		}}
+	case log.KindEmpty:
+		return &commonpb.AnyValue{}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.AsString()}}
+	}
+}
+
+func otlpLogKeyValues(keyValues []log.KeyValue) []*commonpb.KeyValue {
+	encoded := make([]*commonpb.KeyValue, len(keyValues))
+	for index, keyValue := range keyValues {
+		encoded[index] = &commonpb.KeyValue{Key: keyValue.Key, Value: otlpLogValue(keyValue.Value)}
+	}
+
+	return encoded
+}
+
+func otlpResourceOf(res *resource.Resource) *resourcepb.Resource {
+	return &resourcepb.Resource{Attributes: otlpAttributes(res.Attributes())}
+}
+
+func otlpScopeOf(scope instrumentation.Scope) *commonpb.InstrumentationScope {
+	return &commonpb.InstrumentationScope{
+		Name:       scope.Name,
+		Version:    scope.Version,
+		Attributes: otlpAttributes(scope.Attributes.ToSlice()),
+	}
+}
+
+// otlpSpanID encode a span ID, unset when invalid
+func otlpSpanID(spanID trace.SpanID) []byte {
+	if !spanID.IsValid() {
+		return nil
+	}
+
+	return spanID[:]
+}
+
+// otlpTraceID encode a trace ID, unset when invalid
+func otlpTraceID(traceID trace.TraceID) []byte {
+	if !traceID.IsValid() {
+		return nil
+	}
+
+	return traceID[:]
+}
+
+func otlpStatusOf(status sdktrace.Status) *tracepb.Status {
+	switch status.Code {
+	case codes.Ok:
+		return &tracepb.Status{Code: tracepb.Status_STATUS_CODE_OK}
+	case codes.Error:
+		return &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: status.Description}
+	default:
+		return &tracepb.Status{Code: tracepb.Status_STATUS_CODE_UNSET}
+	}
+}
+
+func otlpTemporality(temporality metricdata.Temporality) metricspb.AggregationTemporality {
+	switch temporality {
+	case metricdata.DeltaTemporality:
+		return metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	case metricdata.CumulativeTemporality:
+		return metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	default:
+		return metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+	}
+}
+
+// resourceKey and scopeKey group the telemetry by resource and scope
+type (
+	resourceKey struct {
+		attributes attribute.Distinct
+		schemaURL  string
+	}
+	scopeKey struct {
+		resource  resourceKey
+		name      string
+		version   string
+		schemaURL string
+	}
+)
+
+func newResourceKey(res *resource.Resource) resourceKey {
+	return resourceKey{attributes: res.Equivalent(), schemaURL: res.SchemaURL()}
+}
+
+func newScopeKey(resource resourceKey, scope instrumentation.Scope) scopeKey {
+	return scopeKey{resource: resource, name: scope.Name, version: scope.Version, schemaURL: scope.SchemaURL}
+}
+
+//nolint:gosec
+func otlpSpanOf(span sdktrace.ReadOnlySpan) *tracepb.Span {
+	spanContext := span.SpanContext()
+
+	encoded := &tracepb.Span{
+		TraceId:                otlpTraceID(spanContext.TraceID()),
+		SpanId:                 otlpSpanID(spanContext.SpanID()),
+		TraceState:             spanContext.TraceState().String(),
+		ParentSpanId:           otlpSpanID(span.Parent().SpanID()),
+		Flags:                  uint32(spanContext.TraceFlags()) & otlpTraceFlagsMask,
+		Name:                   span.Name(),
+		Kind:                   tracepb.Span_SpanKind(span.SpanKind()),
+		StartTimeUnixNano:      otlpTime(span.StartTime()),
+		EndTimeUnixNano:        otlpTime(span.EndTime()),
+		Attributes:             otlpAttributes(span.Attributes()),
+		DroppedAttributesCount: uint32(span.DroppedAttributes()),
+		DroppedEventsCount:     uint32(span.DroppedEvents()),
+		DroppedLinksCount:      uint32(span.DroppedLinks()),
+		Status:                 otlpStatusOf(span.Status()),
+	}
+
+	for _, event := range span.Events() {
+		encoded.Events = append(encoded.Events, &tracepb.Span_Event{
+			TimeUnixNano:           otlpTime(event.Time),
+			Name:                   event.Name,
+			Attributes:             otlpAttributes(event.Attributes),
+			DroppedAttributesCount: uint32(event.DroppedAttributeCount),
+		})
+	}
+
+	for _, link := range span.Links() {
+		encoded.Links = append(encoded.Links, &tracepb.Span_Link{
+			TraceId:                otlpTraceID(link.SpanContext.TraceID()),
+			SpanId:                 otlpSpanID(link.SpanContext.SpanID()),
+			TraceState:             link.SpanContext.TraceState().String(),
+			Attributes:             otlpAttributes(link.Attributes),
+			DroppedAttributesCount: uint32(link.DroppedAttributeCount),
+			Flags:                  uint32(link.SpanContext.TraceFlags()) & otlpTraceFlagsMask,
+		})
+	}
+
+	return encoded
+}
+
+// otlpTracesDataOf group spans by resource and scope
+func otlpTracesDataOf(spans []sdktrace.ReadOnlySpan) *tracepb.TracesData {
+	var (
+		data      = &tracepb.TracesData{}
+		resources = make(map[resourceKey]*tracepb.ResourceSpans)
+		scopes    = make(map[scopeKey]*tracepb.ScopeSpans)
+	)
+
+	for _, span := range spans {
+		resKey := newResourceKey(span.Resource())
+
+		resourceSpans, exists := resources[resKey]
+		if !exists {
+			resourceSpans = &tracepb.ResourceSpans{
+				Resource:  otlpResourceOf(span.Resource()),
+				SchemaUrl: span.Resource().SchemaURL(),
+			}
+			resources[resKey] = resourceSpans
+			data.ResourceSpans = append(data.ResourceSpans, resourceSpans)
+		}
+
+		scope := span.InstrumentationScope()
+		scKey := newScopeKey(resKey, scope)
+
+		scopeSpans, exists := scopes[scKey]
+		if !exists {
+			scopeSpans = &tracepb.ScopeSpans{Scope: otlpScopeOf(scope), SchemaUrl: scope.SchemaURL}
+			scopes[scKey] = scopeSpans
+			resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, scopeSpans)
+		}
+
+		scopeSpans.Spans = append(scopeSpans.Spans, otlpSpanOf(span))
+	}
+
+	return data
+}
+
+//nolint:gosec
+func otlpLogRecordOf(record *sdklog.Record) *logspb.LogRecord {
+	encoded := &logspb.LogRecord{
+		TimeUnixNano:           otlpTime(record.Timestamp()),
+		ObservedTimeUnixNano:   otlpTime(record.ObservedTimestamp()),
+		SeverityNumber:         logspb.SeverityNumber(record.Severity()),
+		SeverityText:           record.SeverityText(),
+		DroppedAttributesCount: uint32(record.DroppedAttributes()),
+		Flags:                  uint32(record.TraceFlags()) & otlpTraceFlagsMask,
+		TraceId:                otlpTraceID(record.TraceID()),
+		SpanId:                 otlpSpanID(record.SpanID()),
+		EventName:              record.EventName(),
+	}
+
+	if body := record.Body(); !body.Empty() {
+		encoded.Body = otlpLogValue(body)
+	}
+
+	record.WalkAttributes(func(keyValue log.KeyValue) bool {
+		encoded.Attributes = append(encoded.Attributes, &commonpb.KeyValue{
+			Key:   keyValue.Key,
+			Value: otlpLogValue(keyValue.Value),
+		})
+
+		return true
+	})
+
+	return encoded
+}
+
+// otlpLogsDataOf group records by resource and scope
+func otlpLogsDataOf(records []sdklog.Record) *logspb.LogsData {
+	var (
+		data      = &logspb.LogsData{}
+		resources = make(map[resourceKey]*logspb.ResourceLogs)
+		scopes    = make(map[scopeKey]*logspb.ScopeLogs)
+	)
+
+	for index := range records {
+		record := &records[index]
+		res := record.Resource()
+		resKey := newResourceKey(&res)
+
+		resourceLogs, exists := resources[resKey]
+		if !exists {
+			resourceLogs = &logspb.ResourceLogs{Resource: otlpResourceOf(&res), SchemaUrl: res.SchemaURL()}
+			resources[resKey] = resourceLogs
+			data.ResourceLogs = append(data.ResourceLogs, resourceLogs)
+		}
+
+		scope := record.InstrumentationScope()
+		scKey := newScopeKey(resKey, scope)
+
+		scopeLogs, exists := scopes[scKey]
+		if !exists {
+			scopeLogs = &logspb.ScopeLogs{Scope: otlpScopeOf(scope), SchemaUrl: scope.SchemaURL}
+			scopes[scKey] = scopeLogs
+			resourceLogs.ScopeLogs = append(resourceLogs.ScopeLogs, scopeLogs)
+		}
+
+		scopeLogs.LogRecords = append(scopeLogs.LogRecords, otlpLogRecordOf(record))
+	}
+
+	return data
+}
+
+func otlpNumberDataPoints[N int64 | float64](points []metricdata.DataPoint[N]) []*metricspb.NumberDataPoint {
+	encoded := make([]*metricspb.NumberDataPoint, len(points))
+
+	for index, point := range points {
+		encoded[index] = &metricspb.NumberDataPoint{
+			Attributes:        otlpAttributes(point.Attributes.ToSlice()),
+			StartTimeUnixNano: otlpTime(point.StartTime),
+			TimeUnixNano:      otlpTime(point.Time),
+		}
+
+		switch value := any(point.Value).(type) {
+		case int64:
+			encoded[index].Value = &metricspb.NumberDataPoint_AsInt{AsInt: value}
+		case float64:
+			encoded[index].Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: value}
+		}
+	}
+
+	return encoded
+}
+
+// otlpExtremum encode the min or max of a histogram, if defined
+func otlpExtremum[N int64 | float64](extremum metricdata.Extrema[N]) *float64 {
+	value, defined := extremum.Value()
+	if !defined {
+		return nil
+	}
+
+	return proto.Float64(float64(value))
+}
+
+func otlpHistogramDataPoints[N int64 | float64](
+	points []metricdata.HistogramDataPoint[N],
+) []*metricspb.HistogramDataPoint {
+	encoded := make([]*metricspb.HistogramDataPoint, len(points))
+
+	for index, point := range points {
+		encoded[index] = &metricspb.HistogramDataPoint{
+			Attributes:        otlpAttributes(point.Attributes.ToSlice()),
+			StartTimeUnixNano: otlpTime(point.StartTime),
+			TimeUnixNano:      otlpTime(point.Time),
+			Count:             point.Count,
+			Sum:               proto.Float64(float64(point.Sum)),
+			BucketCounts:      point.BucketCounts,
+			ExplicitBounds:    point.Bounds,
+			Min:               otlpExtremum(point.Min),
+			Max:               otlpExtremum(point.Max),
+		}
+	}
+
+	return encoded
+}
+
+func otlpExponentialHistogramDataPoints[N int64 | float64](
+	points []metricdata.ExponentialHistogramDataPoint[N],
+) []*metricspb.ExponentialHistogramDataPoint {
+	encoded := make([]*metricspb.ExponentialHistogramDataPoint, len(points))
+
+	for index, point := range points {
+		encoded[index] = &metricspb.ExponentialHistogramDataPoint{
+			Attributes:        otlpAttributes(point.Attributes.ToSlice()),
+			StartTimeUnixNano: otlpTime(point.StartTime),
+			TimeUnixNano:      otlpTime(point.Time),
+			Count:             point.Count,
+			Sum:               proto.Float64(float64(point.Sum)),
+			Scale:             point.Scale,
+			ZeroCount:         point.ZeroCount,
+			Positive: &metricspb.ExponentialHistogramDataPoint_Buckets{
+				Offset:       point.PositiveBucket.Offset,
+				BucketCounts: point.PositiveBucket.Counts,
+			},
+			Negative: &metricspb.ExponentialHistogramDataPoint_Buckets{
+				Offset:       point.NegativeBucket.Offset,
+				BucketCounts: point.NegativeBucket.Counts,
+			},
+			Min:           otlpExtremum(point.Min),
+			Max:           otlpExtremum(point.Max),
+			ZeroThreshold: point.ZeroThreshold,
+		}
+	}
+
+	return encoded
+}
+
+func otlpSummaryDataPoints(points []metricdata.SummaryDataPoint) []*metricspb.SummaryDataPoint {
+	encoded := make([]*metricspb.SummaryDataPoint, len(points))
+
+	for index, point := range points {
+		encoded[index] = &metricspb.SummaryDataPoint{
+			Attributes:        otlpAttributes(point.Attributes.ToSlice()),
+			StartTimeUnixNano: otlpTime(point.StartTime),
+			TimeUnixNano:      otlpTime(point.Time),
+			Count:             point.Count,
+			Sum:               point.Sum,
+		}
+
+		for _, quantile := range point.QuantileValues {
+			encoded[index].QuantileValues = append(encoded[index].QuantileValues,
+				&metricspb.SummaryDataPoint_ValueAtQuantile{Quantile: quantile.Quantile, Value: quantile.Value})
+		}
+	}
+
+	return encoded
+}
+
+//nolint:cyclop
+func otlpMetricOf(metric metricdata.Metrics) *metricspb.Metric {
+	encoded := &metricspb.Metric{
+		Name:        metric.Name,
+		Description: metric.Description,
+		Unit:        metric.Unit,
+	}
+
+	switch data := metric.Data.(type) {
+	case metricdata.Gauge[int64]:
+		encoded.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+			DataPoints: otlpNumberDataPoints(data.DataPoints),
+		}}
+	case metricdata.Gauge[float64]:
+		encoded.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+			DataPoints: otlpNumberDataPoints(data.DataPoints),
+		}}
+	case metricdata.Sum[int64]:
+		encoded.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			DataPoints:             otlpNumberDataPoints(data.DataPoints),
+			AggregationTemporality: otlpTemporality(data.Temporality),
+			IsMonotonic:            data.IsMonotonic,
+		}}
+	case metricdata.Sum[float64]:
+		encoded.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			DataPoints:             otlpNumberDataPoints(data.DataPoints),
+			AggregationTemporality: otlpTemporality(data.Temporality),
+			IsMonotonic:            data.IsMonotonic,
+		}}
+	case metricdata.Histogram[int64]:
+		encoded.Data = &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			DataPoints:             otlpHistogramDataPoints(data.DataPoints),
+			AggregationTemporality: otlpTemporality(data.Temporality),
+		}}
+	case metricdata.Histogram[float64]:
+		encoded.Data = &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			DataPoints:             otlpHistogramDataPoints(data.DataPoints),
+			AggregationTemporality: otlpTemporality(data.Temporality),
+		}}
+	case metricdata.ExponentialHistogram[int64]:
+		encoded.Data = &metricspb.Metric_ExponentialHistogram{ExponentialHistogram: &metricspb.ExponentialHistogram{
+			DataPoints:             otlpExponentialHistogramDataPoints(data.DataPoints),
+			AggregationTemporality: otlpTemporality(data.Temporality),
+		}}
+	case metricdata.ExponentialHistogram[float64]:
+		encoded.Data = &metricspb.Metric_ExponentialHistogram{ExponentialHistogram: &metricspb.ExponentialHistogram{
+			DataPoints:             otlpExponentialHistogramDataPoints(data.DataPoints),
+			AggregationTemporality: otlpTemporality(data.Temporality),
+		}}
+	case metricdata.Summary:
+		encoded.Data = &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+			DataPoints: otlpSummaryDataPoints(data.DataPoints),
+		}}
+	}
+
+	return encoded
+}
+
+func otlpMetricsDataOf(metrics *metricdata.ResourceMetrics) *metricspb.MetricsData {
+	res := metrics.Resource
+	if res == nil {
+		res = resource.Empty()
+	}
+
+	resourceMetrics := &metricspb.ResourceMetrics{
+		Resource:  otlpResourceOf(res),
+		SchemaUrl: res.SchemaURL(),
+	}
+
+	for _, scopeMetrics := range metrics.ScopeMetrics {
+		encoded := &metricspb.ScopeMetrics{
+			Scope:     otlpScopeOf(scopeMetrics.Scope),
+			SchemaUrl: scopeMetrics.Scope.SchemaURL,
+		}
+
+		for _, metric := range scopeMetrics.Metrics {
+			encoded.Metrics = append(encoded.Metrics, otlpMetricOf(metric))
+		}
+
+		resourceMetrics.ScopeMetrics = append(resourceMetrics.ScopeMetrics, encoded)
+	}
+
+	return &metricspb.MetricsData{ResourceMetrics: []*metricspb.ResourceMetrics{resourceMetrics}}
+}
@@ -0,0 +1,305 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+)
+
+// File exporter environment variable keys
+const (
+	// otelExporterFileTracesPathEnvKey is the file the spans are written to
+	otelExporterFileTracesPathEnvKey = "OTEL_EXPORTER_FILE_TRACES_PATH"
+	// otelExporterFileLogsPathEnvKey is the file the logs are written to
+	otelExporterFileLogsPathEnvKey = "OTEL_EXPORTER_FILE_LOGS_PATH"
+	// otelExporterFileMetricsPathEnvKey is the file the metrics are written to
+	otelExporterFileMetricsPathEnvKey = "OTEL_EXPORTER_FILE_METRICS_PATH"
+	// otelExporterFileDirectoryEnvKey is the directory of the files whose
+	// path is not defined
+	otelExporterFileDirectoryEnvKey = "OTEL_EXPORTER_FILE_DIRECTORY"
+	// otelExporterFileMaxSizeEnvKey is the size in bytes a file is rotated at
+	otelExporterFileMaxSizeEnvKey = "OTEL_EXPORTER_FILE_MAX_SIZE"
+	// otelExporterFileRotationIntervalEnvKey is the age a file is rotated at
+	otelExporterFileRotationIntervalEnvKey = "OTEL_EXPORTER_FILE_ROTATION_INTERVAL"
+	// otelExporterFileMaxBackupsEnvKey is the number of rotated files kept
+	otelExporterFileMaxBackupsEnvKey = "OTEL_EXPORTER_FILE_MAX_BACKUPS"
+	// otelExporterFileMaxAgeEnvKey is the age rotated files are removed at
+	otelExporterFileMaxAgeEnvKey = "OTEL_EXPORTER_FILE_MAX_AGE"
+)
+
+// File exporter defaults
+const (
+	defaultFileTracesName    = "traces.jsonl"
+	defaultFileLogsName      = "logs.jsonl"
+	defaultFileMetricsName   = "metrics.jsonl"
+	defaultFileMaxSize       = 100 << 20
+	defaultFileMaxBackups    = 7
+	fileRotationTimeLayout   = "20060102T150405.000000000"
+	filePermissions          = 0o600
+	fileDirectoryPermissions = 0o750
+)
+
+// rotatingFileConfig configure a rotatingFile
+type rotatingFileConfig struct {
+	path string
+	// maxSize in bytes rotate the file before it grows over it, zero disable
+	maxSize int64
+	// rotationInterval rotate the file once it is that old, zero disable
+	rotationInterval time.Duration
+	// maxBackups is the number of rotated files kept, zero keep all
+	maxBackups int
+	// maxAge remove the rotated files older than it, zero keep all
+	maxAge time.Duration
+}
+
+// rotatingFileConfigFromEnv read the configuration of the file of a signal,
+// at the path pathEnvKey or named defaultName in the directory
+// OTEL_EXPORTER_FILE_DIRECTORY
+func rotatingFileConfigFromEnv(pathEnvKey, defaultName string) (rotatingFileConfig, error) {
+	config := rotatingFileConfig{
+		path:       os.Getenv(pathEnvKey),
+		maxSize:    defaultFileMaxSize,
+		maxBackups: defaultFileMaxBackups,
+	}
+
+	if config.path == "" {
+		directory := os.Getenv(otelExporterFileDirectoryEnvKey)
+		if directory == "" {
+			return config, errors.Errorf("%s or %s must be defined", pathEnvKey, otelExporterFileDirectoryEnvKey)
+		}
+
+		config.path = filepath.Join(directory, defaultName)
+	}
+
+	var err error
+
+	if value := os.Getenv(otelExporterFileMaxSizeEnvKey); value != "" {
+		if config.maxSize, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return config, errors.Wrap(err, otelExporterFileMaxSizeEnvKey)
+		}
+	}
+
+	if value := os.Getenv(otelExporterFileRotationIntervalEnvKey); value != "" {
+		if config.rotationInterval, err = time.ParseDuration(value); err != nil {
+			return config, errors.Wrap(err, otelExporterFileRotationIntervalEnvKey)
+		}
+	}
+
+	if value := os.Getenv(otelExporterFileMaxBackupsEnvKey); value != "" {
+		if config.maxBackups, err = strconv.Atoi(value); err != nil {
+			return config, errors.Wrap(err, otelExporterFileMaxBackupsEnvKey)
+		}
+	}
+
+	if value := os.Getenv(otelExporterFileMaxAgeEnvKey); value != "" {
+		if config.maxAge, err = time.ParseDuration(value); err != nil {
+			return config, errors.Wrap(err, otelExporterFileMaxAgeEnvKey)
+		}
+	}
+
+	return config, nil
+}
+
+// rotatingFile write lines to a file rotated by size and age, the rotated
+// files being named after the time of their rotation and pruned by count and
+// age
+type rotatingFile struct {
+	config   rotatingFileConfig
+	now      func() time.Time
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// newRotatingFile open the file of config, creating its directory
+func newRotatingFile(config rotatingFileConfig) (*rotatingFile, error) {
+	writer := &rotatingFile{
+		config: config,
+		now:    time.Now,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.path), fileDirectoryPermissions); err != nil {
+		return nil, errors.Wrap(err, "os.MkdirAll")
+	}
+
+	if err := writer.open(); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// open the file for appending
+func (writer *rotatingFile) open() error {
+	file, err := os.OpenFile(writer.config.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePermissions)
+	if err != nil {
+		return errors.Wrap(err, "os.OpenFile")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return errors.Wrap(err, "file.Stat")
+	}
+
+	writer.file = file
+	writer.size = info.Size()
+	writer.openedAt = writer.now()
+
+	return nil
+}
+
+// WriteLine write line followed by a newline, rotating the file before if
+// needed
+func (writer *rotatingFile) WriteLine(line []byte) error {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+
+	if writer.file == nil {
+		return os.ErrClosed
+	}
+
+	if writer.shouldRotate(int64(len(line) + 1)) {
+		if err := writer.rotate(); err != nil {
+			if writer.file == nil {
+				return err
+			}
+
+			// the line is still written to the current file
+			otel.Handle(err)
+		}
+	}
+
+	written, err := writer.file.Write(append(line, '\n'))
+	writer.size += int64(written)
+
+	if err != nil {
+		return errors.Wrap(err, "file.Write")
+	}
+
+	return nil
+}
+
+// shouldRotate tell if writing length bytes need a new file
+func (writer *rotatingFile) shouldRotate(length int64) bool {
+	if writer.size == 0 {
+		return false
+	}
+
+	if writer.config.maxSize > 0 && writer.size+length > writer.config.maxSize {
+		return true
+	}
+
+	return writer.config.rotationInterval > 0 && writer.now().Sub(writer.openedAt) >= writer.config.rotationInterval
+}
+
+// backupPrefixAndExtension split the path of the file around the rotation time
+func (writer *rotatingFile) backupPrefixAndExtension() (string, string) {
+	extension := filepath.Ext(writer.config.path)
+
+	return strings.TrimSuffix(writer.config.path, extension) + "-", extension
+}
+
+// rotate rename the file after the current time, open a new one and prune
+// the rotated files, a failure to prune being reported to the otel handler
+func (writer *rotatingFile) rotate() error {
+	if err := writer.file.Close(); err != nil {
+		return errors.Wrap(err, "file.Close")
+	}
+
+	writer.file = nil
+
+	prefix, extension := writer.backupPrefixAndExtension()
+	backup := prefix + writer.now().UTC().Format(fileRotationTimeLayout) + extension
+
+	if err := os.Rename(writer.config.path, backup); err != nil {
+		// keep appending to the current file
+		if openErr := writer.open(); openErr != nil {
+			return wrapMultiErrors([]error{errors.Wrap(err, "os.Rename"), openErr})
+		}
+
+		return errors.Wrap(err, "os.Rename")
+	}
+
+	if err := writer.open(); err != nil {
+		return err
+	}
+
+	if err := writer.prune(); err != nil {
+		otel.Handle(err)
+	}
+
+	return nil
+}
+
+// prune remove the rotated files over maxBackups or older than maxAge
+func (writer *rotatingFile) prune() error {
+	prefix, extension := writer.backupPrefixAndExtension()
+
+	matches, err := filepath.Glob(prefix + "*" + extension)
+	if err != nil {
+		return errors.Wrap(err, "filepath.Glob")
+	}
+
+	type backup struct {
+		path      string
+		rotatedAt time.Time
+	}
+
+	backups := make([]backup, 0, len(matches))
+
+	// only the files named after a rotation time are backups
+	for _, match := range matches {
+		rotatedAt, err := time.Parse(
+			fileRotationTimeLayout,
+			strings.TrimSuffix(strings.TrimPrefix(match, prefix), extension),
+		)
+		if err == nil {
+			backups = append(backups, backup{path: match, rotatedAt: rotatedAt})
+		}
+	}
+
+	// newest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotatedAt.After(backups[j].rotatedAt)
+	})
+
+	var errs []error
+
+	for index, backup := range backups {
+		tooMany := writer.config.maxBackups > 0 && index >= writer.config.maxBackups
+		tooOld := writer.config.maxAge > 0 && writer.now().Sub(backup.rotatedAt) > writer.config.maxAge
+
+		if tooMany || tooOld {
+			if err := os.Remove(backup.path); err != nil {
+				errs = append(errs, errors.Wrap(err, "os.Remove"))
+			}
+		}
+	}
+
+	return wrapMultiErrors(errs)
+}
+
+// Close the file, further writes fail
+func (writer *rotatingFile) Close() error {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+
+	if writer.file == nil {
+		return nil
+	}
+
+	err := writer.file.Close()
+	writer.file = nil
+
+	return errors.Wrap(err, "file.Close")
+}
@@ -0,0 +1,180 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a time advanced by the tests
+type fakeClock struct {
+	current time.Time
+}
+
+func (clock *fakeClock) now() time.Time {
+	return clock.current
+}
+
+func (clock *fakeClock) advance(duration time.Duration) {
+	clock.current = clock.current.Add(duration)
+}
+
+func newTestRotatingFile(t *testing.T, config rotatingFileConfig) (*rotatingFile, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{current: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	writer, err := newRotatingFile(config)
+	require.NoError(t, err)
+
+	writer.now = clock.now
+	writer.openedAt = clock.now()
+
+	t.Cleanup(func() {
+		assert.NoError(t, writer.Close())
+	})
+
+	return writer, clock
+}
+
+func backups(t *testing.T, directory string) []string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(directory, "traces-*.jsonl"))
+	require.NoError(t, err)
+
+	names := make([]string, len(matches))
+	for index, match := range matches {
+		names[index] = filepath.Base(match)
+	}
+
+	return names
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		path      = filepath.Join(directory, "nested", "traces.jsonl")
+	)
+
+	writer, clock := newTestRotatingFile(t, rotatingFileConfig{path: path, maxSize: 20})
+
+	require.NoError(t, writer.WriteLine([]byte("123456789")))
+	require.NoError(t, writer.WriteLine([]byte("123456789")))
+
+	clock.advance(time.Second)
+	require.NoError(t, writer.WriteLine([]byte("abcdefghi")))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "abcdefghi\n", string(content))
+
+	rotated, err := os.ReadFile(filepath.Join(directory, "nested", "traces-20260102T030406.000000000.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, "123456789\n123456789\n", string(rotated))
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		path      = filepath.Join(directory, "traces.jsonl")
+	)
+
+	writer, clock := newTestRotatingFile(t, rotatingFileConfig{path: path, rotationInterval: time.Hour})
+
+	require.NoError(t, writer.WriteLine([]byte("first")))
+
+	clock.advance(time.Minute)
+	require.NoError(t, writer.WriteLine([]byte("second")))
+	assert.Empty(t, backups(t, directory))
+
+	clock.advance(time.Hour)
+	require.NoError(t, writer.WriteLine([]byte("third")))
+	assert.Equal(t, []string{"traces-20260102T040505.000000000.jsonl"}, backups(t, directory))
+}
+
+func TestRotatingFileRetention(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		path      = filepath.Join(directory, "traces.jsonl")
+		unrelated = filepath.Join(directory, "traces-notes.jsonl")
+	)
+
+	require.NoError(t, os.WriteFile(unrelated, []byte("notes"), filePermissions))
+
+	writer, clock := newTestRotatingFile(t, rotatingFileConfig{
+		path:       path,
+		maxSize:    1,
+		maxBackups: 2,
+		maxAge:     90 * time.Minute,
+	})
+
+	for range 4 {
+		require.NoError(t, writer.WriteLine([]byte("line")))
+		clock.advance(time.Minute)
+	}
+
+	assert.Equal(t, []string{
+		"traces-20260102T030605.000000000.jsonl",
+		"traces-20260102T030705.000000000.jsonl",
+		"traces-notes.jsonl",
+	}, backups(t, directory))
+
+	clock.advance(2 * time.Hour)
+	require.NoError(t, writer.WriteLine([]byte("line")))
+
+	assert.Equal(t, []string{
+		"traces-20260102T050805.000000000.jsonl",
+		"traces-notes.jsonl",
+	}, backups(t, directory))
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	writer, err := newRotatingFile(rotatingFileConfig{path: filepath.Join(t.TempDir(), "traces.jsonl")})
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Close())
+	require.NoError(t, writer.Close())
+	assert.ErrorIs(t, writer.WriteLine([]byte("line")), os.ErrClosed)
+}
+
+func TestRotatingFileConfigFromEnv(t *testing.T) {
+	// the path is not relative to the working directory by default
+	_, err := rotatingFileConfigFromEnv(otelExporterFileTracesPathEnvKey, defaultFileTracesName)
+	require.ErrorContains(t, err, otelExporterFileDirectoryEnvKey)
+
+	t.Setenv(otelExporterFileDirectoryEnvKey, "/var/log/mcp")
+
+	config, err := rotatingFileConfigFromEnv(otelExporterFileTracesPathEnvKey, defaultFileTracesName)
+	require.NoError(t, err)
+	assert.Equal(t, rotatingFileConfig{
+		path:       "/var/log/mcp/traces.jsonl",
+		maxSize:    defaultFileMaxSize,
+		maxBackups: defaultFileMaxBackups,
+	}, config)
+
+	t.Setenv(otelExporterFileTracesPathEnvKey, "/var/log/traces.jsonl")
+	t.Setenv(otelExporterFileMaxSizeEnvKey, "1024")
+	t.Setenv(otelExporterFileRotationIntervalEnvKey, "24h")
+	t.Setenv(otelExporterFileMaxBackupsEnvKey, "3")
+	t.Setenv(otelExporterFileMaxAgeEnvKey, "168h")
+
+	config, err = rotatingFileConfigFromEnv(otelExporterFileTracesPathEnvKey, defaultFileTracesName)
+	require.NoError(t, err)
+	assert.Equal(t, rotatingFileConfig{
+		path:             "/var/log/traces.jsonl",
+		maxSize:          1024,
+		rotationInterval: 24 * time.Hour,
+		maxBackups:       3,
+		maxAge:           168 * time.Hour,
+	}, config)
+
+	t.Setenv(otelExporterFileMaxAgeEnvKey, "a week")
+
+	_, err = rotatingFileConfigFromEnv(otelExporterFileTracesPathEnvKey, defaultFileTracesName)
+	assert.ErrorContains(t, err, otelExporterFileMaxAgeEnvKey)
+}
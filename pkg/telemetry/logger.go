@@ -0,0 +1,39 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/log"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+)
+
+// loggerKey is the context key of the logger
+type loggerKey struct{}
+
+// NewLogger create a slog.Logger emitting its records to provider. Records
+// logged with a context carry the trace and span IDs of its span. The
+// resolved secrets are redacted (see secret.Redact).
+func NewLogger(provider log.LoggerProvider, serviceName, version string) *slog.Logger {
+	return slog.New(secret.NewRedactingHandler(otelslog.NewHandler(
+		serviceName,
+		otelslog.WithLoggerProvider(provider),
+		otelslog.WithVersion(version),
+	)))
+}
+
+// ContextWithLogger return a copy of ctx carrying logger
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext return the logger carried by ctx, or slog.Default()
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, isLogger := ctx.Value(loggerKey{}).(*slog.Logger); isLogger {
+		return logger
+	}
+
+	return slog.Default()
+}
@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// recordingProcessor keep the emitted records
+type recordingProcessor struct {
+	mutex   sync.Mutex
+	records []sdklog.Record
+}
+
+func (processor *recordingProcessor) OnEmit(_ context.Context, record *sdklog.Record) error {
+	processor.mutex.Lock()
+	defer processor.mutex.Unlock()
+
+	processor.records = append(processor.records, record.Clone())
+
+	return nil
+}
+
+func (processor *recordingProcessor) Shutdown(context.Context) error {
+	return nil
+}
+
+func (processor *recordingProcessor) ForceFlush(context.Context) error {
+	return nil
+}
+
+func TestNewLogger(t *testing.T) {
+	processor := &recordingProcessor{}
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(processor))
+
+	logger := NewLogger(provider, "test-service", "1.0.0")
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(t.Context(), "test-span")
+	logger.InfoContext(ctx, "within span", slog.String("key", "value"))
+	span.End()
+
+	logger.Info("without span")
+
+	require.Len(t, processor.records, 2)
+
+	assert.Equal(t, "within span", processor.records[0].Body().AsString())
+	assert.Equal(t, span.SpanContext().TraceID(), processor.records[0].TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), processor.records[0].SpanID())
+	assert.Equal(t, "test-service", processor.records[0].InstrumentationScope().Name)
+	assert.Equal(t, "1.0.0", processor.records[0].InstrumentationScope().Version)
+
+	assert.False(t, processor.records[1].TraceID().IsValid())
+}
+
+func TestLoggerFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), LoggerFromContext(t.Context()))
+
+	logger := slog.New(slog.DiscardHandler)
+	assert.Equal(t, logger, LoggerFromContext(ContextWithLogger(t.Context(), logger)))
+}
@@ -0,0 +1,118 @@
+package telemetry
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// options of InitTelemetry
+type options struct {
+	resourceAttributes []attribute.KeyValue
+	sampler            sdktrace.Sampler
+	spanProcessors     []sdktrace.SpanProcessor
+	metricReaders      []metric.Reader
+	logProcessors      []log.Processor
+	propagators        []propagation.TextMapPropagator
+	withoutGlobals     bool
+	withoutDetectors   bool
+	prometheus         bool
+}
+
+// Option configure InitTelemetry
+type Option func(*options)
+
+// newOptions return the default options modified by opts
+func newOptions(opts []Option) *options {
+	result := &options{}
+
+	for _, opt := range opts {
+		opt(result)
+	}
+
+	if len(result.propagators) == 0 {
+		result.propagators = []propagation.TextMapPropagator{
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		}
+	}
+
+	return result
+}
+
+// WithResourceAttributes add attributes to the resource, over the detected ones
+func WithResourceAttributes(attributes ...attribute.KeyValue) Option {
+	return func(opts *options) {
+		opts.resourceAttributes = append(opts.resourceAttributes, attributes...)
+	}
+}
+
+// WithSampler sample the traces with sampler instead of the one set by
+// OTEL_TRACES_SAMPLER
+func WithSampler(sampler sdktrace.Sampler) Option {
+	return func(opts *options) {
+		opts.sampler = sampler
+	}
+}
+
+// WithSpanProcessor process the spans with processor instead of a batch
+// processor of the exporter set by OTEL_TRACES_EXPORTER. Its spans are not
+// redacted unless it export them with NewRedactingSpanExporter
+func WithSpanProcessor(processor sdktrace.SpanProcessor) Option {
+	return func(opts *options) {
+		opts.spanProcessors = append(opts.spanProcessors, processor)
+	}
+}
+
+// WithMetricReader read the metrics with reader instead of the one set by
+// OTEL_METRICS_EXPORTER
+func WithMetricReader(reader metric.Reader) Option {
+	return func(opts *options) {
+		opts.metricReaders = append(opts.metricReaders, reader)
+	}
+}
+
+// WithLogProcessor process the logs with processor instead of a batch
+// processor of the exporter set by OTEL_LOGS_EXPORTER
+func WithLogProcessor(processor log.Processor) Option {
+	return func(opts *options) {
+		opts.logProcessors = append(opts.logProcessors, processor)
+	}
+}
+
+// WithPropagators propagate the context with propagators instead of
+// TraceContext and Baggage
+func WithPropagators(propagators ...propagation.TextMapPropagator) Option {
+	return func(opts *options) {
+		opts.propagators = append(opts.propagators, propagators...)
+	}
+}
+
+// WithoutGlobals do not register the providers and the propagator as the
+// OpenTelemetry globals, they are only available from Telemetry
+func WithoutGlobals() Option {
+	return func(opts *options) {
+		opts.withoutGlobals = true
+	}
+}
+
+// WithoutDetectors do not detect the host and container attributes of the
+// resource, which only has the service, OTEL_RESOURCE_ATTRIBUTES and
+// WithResourceAttributes ones
+func WithoutDetectors() Option {
+	return func(opts *options) {
+		opts.withoutDetectors = true
+	}
+}
+
+// WithPrometheus also read the metrics for Prometheus, along with the Go
+// runtime and process metrics, scraped from Telemetry.MetricsHandler. The
+// metrics are still exported as set by OTEL_METRICS_EXPORTER
+func WithPrometheus() Option {
+	return func(opts *options) {
+		opts.prometheus = true
+	}
+}
@@ -0,0 +1,170 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+func TestInitTelemetryOptions(t *testing.T) {
+	var (
+		recorder       = tracetest.NewSpanRecorder()
+		reader         = sdkmetric.NewManualReader()
+		logs           = &recordingProcessor{}
+		globalProvider = otel.GetTracerProvider()
+	)
+
+	tel, err := InitTelemetry(
+		t.Context(),
+		"test-service",
+		"1.0.0",
+		WithResourceAttributes(attribute.String("deployment.environment", "test")),
+		WithSpanProcessor(recorder),
+		WithMetricReader(reader),
+		WithLogProcessor(logs),
+		WithPropagators(propagation.Baggage{}),
+		WithoutGlobals(),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, tel.Shutdown(t.Context()))
+	}()
+
+	assert.Equal(t, globalProvider, otel.GetTracerProvider(), "globals must be left untouched")
+	assert.Equal(t, []string{"baggage"}, tel.Propagator.Fields())
+
+	ctx, span := tel.TracerProvider.Tracer("test").Start(t.Context(), "test-span")
+	tel.Logger.InfoContext(ctx, "message")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	environment, exists := spans[0].Resource().Set().Value("deployment.environment")
+	require.True(t, exists)
+	assert.Equal(t, "test", environment.AsString())
+	assert.True(t, spans[0].Resource().Set().HasValue(semconv.HostNameKey))
+
+	require.Len(t, logs.records, 1)
+	assert.Equal(t, span.SpanContext().TraceID(), logs.records[0].TraceID())
+
+	counter, err := tel.MeterProvider.Meter("test").Int64Counter("test.counter")
+	require.NoError(t, err)
+	counter.Add(t.Context(), 1)
+
+	var collected metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(t.Context(), &collected))
+	require.Len(t, collected.ScopeMetrics, 1)
+	assert.Equal(t, "test.counter", collected.ScopeMetrics[0].Metrics[0].Name)
+}
+
+func TestNewOptionsDefaultPropagators(t *testing.T) {
+	propagator := propagation.NewCompositeTextMapPropagator(newOptions(nil).propagators...)
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, propagator.Fields())
+}
+
+func TestInitTelemetryWithSampler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	tel, err := InitTelemetry(
+		t.Context(),
+		"test-service",
+		"1.0.0",
+		WithSampler(sdktrace.NeverSample()),
+		WithSpanProcessor(recorder),
+		WithMetricReader(sdkmetric.NewManualReader()),
+		WithLogProcessor(sdklog.NewSimpleProcessor(nil)),
+		WithoutGlobals(),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, tel.Shutdown(t.Context()))
+	}()
+
+	_, span := tel.TracerProvider.Tracer("test").Start(t.Context(), "test-span")
+	span.End()
+
+	assert.Empty(t, recorder.Ended())
+}
+
+func TestInitTelemetryWithoutDetectors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	tel, err := InitTelemetry(
+		t.Context(),
+		"test-service",
+		"1.0.0",
+		WithoutDetectors(),
+		WithSpanProcessor(recorder),
+		WithMetricReader(sdkmetric.NewManualReader()),
+		WithLogProcessor(sdklog.NewSimpleProcessor(nil)),
+		WithoutGlobals(),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, tel.Shutdown(t.Context()))
+	}()
+
+	_, span := tel.TracerProvider.Tracer("test").Start(t.Context(), "test-span")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	attributes := spans[0].Resource().Set()
+	assert.False(t, attributes.HasValue(semconv.HostNameKey))
+
+	service, exists := attributes.Value(semconv.ServiceNameKey)
+	require.True(t, exists)
+	assert.Equal(t, "test-service", service.AsString())
+}
+
+func TestInitTelemetryWithPrometheus(t *testing.T) {
+	t.Setenv(otelTracesExporterEnvKey, exporterNone)
+	t.Setenv(otelMetricsExporterEnvKey, exporterNone)
+	t.Setenv(otelLogsExporterEnvKey, exporterNone)
+
+	tel, err := InitTelemetry(t.Context(), "test-service", "1.0.0", WithoutGlobals())
+	require.NoError(t, err)
+	assert.Nil(t, tel.MetricsHandler)
+	require.NoError(t, tel.Shutdown(t.Context()))
+
+	tel, err = InitTelemetry(t.Context(), "test-service", "1.0.0", WithoutGlobals(), WithPrometheus())
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, tel.Shutdown(t.Context()))
+	}()
+
+	require.NotNil(t, tel.MetricsHandler)
+
+	counter, err := tel.MeterProvider.Meter("test").Int64Counter("test.counter")
+	require.NoError(t, err)
+	counter.Add(t.Context(), 3)
+
+	recorder := httptest.NewRecorder()
+	tel.MetricsHandler.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "test_counter_total")
+	assert.Contains(t, recorder.Body.String(), `service_name="test-service"`)
+	assert.Contains(t, recorder.Body.String(), "go_goroutines")
+	assert.Contains(t, recorder.Body.String(), "process_")
+}
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/sdk/metric"
+
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+)
+
+// newPrometheusReader create a metric reader collected by a registry of its
+// own, with the Go runtime and process collectors, and the handler scraping
+// that registry
+func newPrometheusReader() (metric.Reader, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+
+	if err := registry.Register(collectors.NewGoCollector()); err != nil {
+		return nil, nil, errors.Wrap(err, "registry.Register(GoCollector)")
+	}
+
+	if err := registry.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})); err != nil {
+		return nil, nil, errors.Wrap(err, "registry.Register(ProcessCollector)")
+	}
+
+	reader, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "prometheus.New")
+	}
+
+	return reader, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
@@ -0,0 +1,101 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+)
+
+// redactingSpanExporter is a trace.SpanExporter redacting the spans of its
+// exporter
+type redactingSpanExporter struct {
+	trace.SpanExporter
+}
+
+// NewRedactingSpanExporter create a trace.SpanExporter redacting the resolved
+// secrets (see secret.Redact) from the name, the status, and the attributes of
+// the spans, their events and links before handing them to exporter
+func NewRedactingSpanExporter(exporter trace.SpanExporter) trace.SpanExporter {
+	return &redactingSpanExporter{SpanExporter: exporter}
+}
+
+func (e *redactingSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	redacted := make([]trace.ReadOnlySpan, len(spans))
+	for index, span := range spans {
+		redacted[index] = redactedSpan{ReadOnlySpan: span}
+	}
+
+	return e.SpanExporter.ExportSpans(ctx, redacted) //nolint:wrapcheck
+}
+
+// redactedSpan is a trace.ReadOnlySpan redacting its span
+type redactedSpan struct {
+	trace.ReadOnlySpan
+}
+
+func (s redactedSpan) Name() string {
+	return secret.Redact(s.ReadOnlySpan.Name())
+}
+
+func (s redactedSpan) Attributes() []attribute.KeyValue {
+	return redactAttributes(s.ReadOnlySpan.Attributes())
+}
+
+func (s redactedSpan) Events() []trace.Event {
+	events := s.ReadOnlySpan.Events()
+
+	redacted := make([]trace.Event, len(events))
+	for index, event := range events {
+		event.Name = secret.Redact(event.Name)
+		event.Attributes = redactAttributes(event.Attributes)
+		redacted[index] = event
+	}
+
+	return redacted
+}
+
+func (s redactedSpan) Links() []trace.Link {
+	links := s.ReadOnlySpan.Links()
+
+	redacted := make([]trace.Link, len(links))
+	for index, link := range links {
+		link.Attributes = redactAttributes(link.Attributes)
+		redacted[index] = link
+	}
+
+	return redacted
+}
+
+func (s redactedSpan) Status() trace.Status {
+	status := s.ReadOnlySpan.Status()
+	status.Description = secret.Redact(status.Description)
+
+	return status
+}
+
+// redactAttributes redact the secrets of the string values of attributes
+func redactAttributes(attributes []attribute.KeyValue) []attribute.KeyValue {
+	redacted := make([]attribute.KeyValue, len(attributes))
+
+	for index, attr := range attributes {
+		switch attr.Value.Type() {
+		case attribute.STRING:
+			attr = attr.Key.String(secret.Redact(attr.Value.AsString()))
+		case attribute.STRINGSLICE:
+			values := attr.Value.AsStringSlice()
+			for valueIndex, value := range values {
+				values[valueIndex] = secret.Redact(value)
+			}
+
+			attr = attr.Key.StringSlice(values)
+		default:
+		}
+
+		redacted[index] = attr
+	}
+
+	return redacted
+}
@@ -0,0 +1,51 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+)
+
+func TestRedactingSpanExporter(t *testing.T) {
+	secret.AddRedacted("span-secret")
+
+	var (
+		recorder = tracetest.NewInMemoryExporter()
+		exporter = NewRedactingSpanExporter(recorder)
+		span     = tracetest.SpanStub{
+			Name: "call span-secret",
+			Attributes: []attribute.KeyValue{
+				attribute.String("token", "Bearer span-secret"),
+				attribute.StringSlice("tokens", []string{"span-secret", "public"}),
+				attribute.Int("count", 1),
+			},
+			Events: []trace.Event{{Name: "event", Attributes: []attribute.KeyValue{attribute.String("token", "span-secret")}}},
+			Links:  []trace.Link{{Attributes: []attribute.KeyValue{attribute.String("token", "span-secret")}}},
+			Status: trace.Status{Code: codes.Error, Description: "invalid span-secret"},
+		}
+	)
+
+	require.NoError(t, exporter.ExportSpans(t.Context(), []trace.ReadOnlySpan{span.Snapshot()}))
+
+	spans := recorder.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "call [REDACTED]", spans[0].Name)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("token", "Bearer [REDACTED]"),
+		attribute.StringSlice("tokens", []string{"[REDACTED]", "public"}),
+		attribute.Int("count", 1),
+	}, spans[0].Attributes)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("token", "[REDACTED]")}, spans[0].Events[0].Attributes)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("token", "[REDACTED]")}, spans[0].Links[0].Attributes)
+	assert.Equal(t, "invalid [REDACTED]", spans[0].Status.Description)
+
+	// the original spans are left as is
+	assert.Equal(t, "Bearer span-secret", span.Attributes[0].Value.AsString())
+}
@@ -2,10 +2,12 @@ package telemetry
 
 import (
 	"context"
+	"log/slog"
+	"net/http"
 
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/contrib/exporters/autoexport"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/metric"
@@ -15,79 +17,168 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
-// InitTelemetry initializes OpenTelemetry tracing, metrics and logging.
-// Returns a single shutdown function that handles all components.
-func InitTelemetry(ctx context.Context, serviceName, version string) (func(context.Context) error, error) {
+// Telemetry is the OpenTelemetry tracing, metrics and logging initialized by
+// InitTelemetry
+type Telemetry struct {
+	TracerProvider *sdktrace.TracerProvider
+	MeterProvider  *metric.MeterProvider
+	LoggerProvider *log.LoggerProvider
+	Propagator     propagation.TextMapPropagator
+	// Logger emit to LoggerProvider, correlated with the span of the
+	// context of each record
+	Logger *slog.Logger
+	// MetricsHandler serve the metrics to Prometheus, nil unless
+	// WithPrometheus
+	MetricsHandler http.Handler
+}
+
+// telemetryKey is the context key of the telemetry
+type telemetryKey struct{}
+
+// ContextWithTelemetry return a copy of ctx carrying telemetry
+func ContextWithTelemetry(ctx context.Context, telemetry *Telemetry) context.Context {
+	return context.WithValue(ctx, telemetryKey{}, telemetry)
+}
+
+// FromContext return the telemetry carried by ctx, or nil
+func FromContext(ctx context.Context) *Telemetry {
+	if telemetry, isTelemetry := ctx.Value(telemetryKey{}).(*Telemetry); isTelemetry {
+		return telemetry
+	}
+
+	return nil
+}
+
+// InitTelemetry initializes OpenTelemetry tracing, metrics and logging,
+// exported as configured by the OTEL_* environment variables unless options
+// inject their own processors and readers. The providers and the propagator
+// are registered as the OpenTelemetry globals, unless WithoutGlobals. The
+// resource has the host and container attributes, unless WithoutDetectors.
+// The resolved secrets are redacted from the logs and from the spans exported
+// as configured (see NewRedactingSpanExporter).
+func InitTelemetry(ctx context.Context, serviceName, version string, opts ...Option) (*Telemetry, error) {
+	options := newOptions(opts)
+
 	// Create resource with service name
-	res, err := resource.New(
-		ctx,
+	resourceOptions := []resource.Option{
 		resource.WithAttributes(
 			semconv.ServiceNameKey.String(serviceName),
 			semconv.ServiceVersionKey.String(version),
 		),
 		resource.WithFromEnv(),
-		resource.WithContainer(),
-		resource.WithHost(),
+	}
+
+	if !options.withoutDetectors {
+		resourceOptions = append(resourceOptions, resource.WithContainer(), resource.WithHost())
+	}
+
+	res, err := resource.New(
+		ctx,
+		append(resourceOptions, resource.WithAttributes(options.resourceAttributes...))...,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "resource.New")
 	}
 
 	// Initialize tracing
-	traceExporter, err := autoexport.NewSpanExporter(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "autoexport.NewSpanExporter")
+	traceOptions := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	if options.sampler != nil {
+		traceOptions = append(traceOptions, sdktrace.WithSampler(options.sampler))
 	}
 
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(traceExporter),
-		sdktrace.WithResource(res),
-	)
-	otel.SetTracerProvider(tracerProvider)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
+	if len(options.spanProcessors) == 0 {
+		traceExporter, err := newSpanExporter(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "newSpanExporter")
+		}
 
-	// Initialize metrics
-	metricExporter, err := autoexport.NewMetricReader(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "autoexport.NewMetricReader")
+		traceOptions = append(traceOptions, sdktrace.WithBatcher(NewRedactingSpanExporter(traceExporter)))
 	}
 
-	metricProvider := metric.NewMeterProvider(
-		metric.WithResource(res),
-		metric.WithReader(metricExporter),
-	)
-	otel.SetMeterProvider(metricProvider)
+	for _, processor := range options.spanProcessors {
+		traceOptions = append(traceOptions, sdktrace.WithSpanProcessor(processor))
+	}
 
-	// Initialize logging using our registered composite exporter
-	logExporter, err := autoexport.NewLogExporter(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "autoexport.NewLogExporter")
+	// Initialize metrics
+	metricOptions := []metric.Option{metric.WithResource(res)}
+
+	if len(options.metricReaders) == 0 {
+		metricReader, err := newMetricReader(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "newMetricReader")
+		}
+
+		metricOptions = append(metricOptions, metric.WithReader(metricReader))
 	}
 
-	loggerProvider := log.NewLoggerProvider(
-		log.WithResource(res),
-		log.WithProcessor(log.NewBatchProcessor(logExporter)),
-	)
+	for _, reader := range options.metricReaders {
+		metricOptions = append(metricOptions, metric.WithReader(reader))
+	}
 
-	// Return combined shutdown function
-	return func(ctx context.Context) error {
-		var errs []error
+	var metricsHandler http.Handler
 
-		if err := tracerProvider.Shutdown(ctx); err != nil {
-			errs = append(errs, errors.Wrap(err, "tracerProvider.Shutdown"))
-		}
+	if options.prometheus {
+		var prometheusReader metric.Reader
 
-		if err := metricProvider.Shutdown(ctx); err != nil {
-			errs = append(errs, errors.Wrap(err, "metricProvider.Shutdown"))
+		prometheusReader, metricsHandler, err = newPrometheusReader()
+		if err != nil {
+			return nil, errors.Wrap(err, "newPrometheusReader")
 		}
 
-		if err := loggerProvider.Shutdown(ctx); err != nil {
-			errs = append(errs, errors.Wrap(err, "loggerProvider.Shutdown"))
+		metricOptions = append(metricOptions, metric.WithReader(prometheusReader))
+	}
+
+	// Initialize logging using our registered composite exporter
+	logOptions := []log.LoggerProviderOption{log.WithResource(res)}
+
+	if len(options.logProcessors) == 0 {
+		logExporter, err := newLogExporter(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "newLogExporter")
 		}
 
-		return wrapMultiErrors(errs)
-	}, nil
+		logOptions = append(logOptions, log.WithProcessor(log.NewBatchProcessor(logExporter)))
+	}
+
+	for _, processor := range options.logProcessors {
+		logOptions = append(logOptions, log.WithProcessor(processor))
+	}
+
+	telemetry := &Telemetry{
+		TracerProvider: sdktrace.NewTracerProvider(traceOptions...),
+		MeterProvider:  metric.NewMeterProvider(metricOptions...),
+		LoggerProvider: log.NewLoggerProvider(logOptions...),
+		Propagator:     propagation.NewCompositeTextMapPropagator(options.propagators...),
+		MetricsHandler: metricsHandler,
+	}
+	telemetry.Logger = NewLogger(telemetry.LoggerProvider, serviceName, version)
+
+	if !options.withoutGlobals {
+		otel.SetTracerProvider(telemetry.TracerProvider)
+		otel.SetTextMapPropagator(telemetry.Propagator)
+		otel.SetMeterProvider(telemetry.MeterProvider)
+		global.SetLoggerProvider(telemetry.LoggerProvider)
+	}
+
+	return telemetry, nil
+}
+
+// Shutdown flush and stop all components
+func (telemetry *Telemetry) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if err := telemetry.TracerProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, errors.Wrap(err, "tracerProvider.Shutdown"))
+	}
+
+	if err := telemetry.MeterProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, errors.Wrap(err, "metricProvider.Shutdown"))
+	}
+
+	if err := telemetry.LoggerProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, errors.Wrap(err, "loggerProvider.Shutdown"))
+	}
+
+	return wrapMultiErrors(errs)
 }
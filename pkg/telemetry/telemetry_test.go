@@ -30,9 +30,9 @@ func TestInitTelemetry(t *testing.T) {
 		serviceName := "test-service"
 		version := "1.0.0"
 
-		shutdown, err := InitTelemetry(ctx, serviceName, version)
+		tel, err := InitTelemetry(ctx, serviceName, version)
 		require.NoError(t, err)
-		require.NotNil(t, shutdown)
+		require.NotNil(t, tel.Logger)
 
 		// Verify tracer provider is set
 		tracerProvider := otel.GetTracerProvider()
@@ -40,7 +40,7 @@ func TestInitTelemetry(t *testing.T) {
 		assert.True(t, ok, "expected sdktrace.TracerProvider")
 
 		// Test shutdown - ignore metrics errors since we don't have collector running
-		err = shutdown(ctx)
+		err = tel.Shutdown(ctx)
 		if err != nil {
 			assert.Contains(t, err.Error(), "metricProvider.Shutdown")
 		}
@@ -64,10 +64,10 @@ func TestResourceAttributes(t *testing.T) {
 	serviceName := "test-service"
 	version := "1.0.0"
 
-	shutdown, err := InitTelemetry(t.Context(), serviceName, version)
+	tel, err := InitTelemetry(t.Context(), serviceName, version)
 	require.NoError(t, err)
 	defer func() {
-		_ = shutdown(t.Context())
+		_ = tel.Shutdown(t.Context())
 	}()
 
 	// Verify the global tracer provider has our service name
@@ -87,3 +87,10 @@ func TestResourceAttributes(t *testing.T) {
 	assert.Equal(t, serviceName, attrMap[string(semconv.ServiceNameKey)])
 	assert.Equal(t, version, attrMap[string(semconv.ServiceVersionKey)])
 }
+
+func TestFromContext(t *testing.T) {
+	assert.Nil(t, FromContext(t.Context()))
+
+	tel := &Telemetry{}
+	assert.Same(t, tel, FromContext(ContextWithTelemetry(t.Context(), tel)))
+}
@@ -0,0 +1,445 @@
+package tools
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/pkg/errors"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+	"github.com/transform-ia/mcp-tools/pkg/telemetry"
+)
+
+// Authentication methods of a Principal
+const (
+	// AuthMethodBearer authenticate with a static bearer token
+	AuthMethodBearer = "bearer"
+	// AuthMethodJWT authenticate with a JWT bearer token
+	AuthMethodJWT = "jwt"
+	// AuthMethodMTLS authenticate with a TLS client certificate
+	AuthMethodMTLS = "mtls"
+)
+
+const (
+	// envAuthTokenPrefix prefix the environment variables of the static bearer
+	// tokens, MCP_AUTH_TOKEN_<principal>=<token>
+	envAuthTokenPrefix = "MCP_AUTH_TOKEN"
+	// envAuthJWKSFile is the JWKS file the JWT are verified with
+	envAuthJWKSFile = "MCP_AUTH_JWKS_FILE"
+	// envAuthJWTIssuer is the issuer the JWT must have, if defined
+	envAuthJWTIssuer = "MCP_AUTH_JWT_ISSUER"
+	// envAuthJWTAudience is the audience the JWT must have, if defined
+	envAuthJWTAudience = "MCP_AUTH_JWT_AUDIENCE"
+	bearerScheme       = "Bearer"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request does not
+// carry the credentials it authenticate
+var ErrNoCredentials = errors.New("no credentials")
+
+// jwtSignatureAlgorithms are the algorithms the JWT can be signed with
+var jwtSignatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Principal is the authenticated caller of a tool
+type Principal struct {
+	// Name of the caller: the name of its static token, the subject of its
+	// JWT or the subject of its certificate
+	Name string
+	// Method is the authentication method, like AuthMethodJWT
+	Method string
+	// Claims of the JWT, nil for other methods
+	Claims map[string]any
+}
+
+// principalKey is the context key of the principal
+type principalKey struct{}
+
+// ContextWithPrincipal return a copy of ctx carrying principal
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext return the principal carried by ctx, nil when the
+// caller is not authenticated
+func PrincipalFromContext(ctx context.Context) *Principal {
+	if principal, isPrincipal := ctx.Value(principalKey{}).(*Principal); isPrincipal {
+		return principal
+	}
+
+	return nil
+}
+
+// Authenticator authenticate the caller of an HTTP request, returning
+// ErrNoCredentials when the request does not carry its kind of credentials
+type Authenticator interface {
+	Authenticate(request *http.Request) (*Principal, error)
+}
+
+// chainAuthenticator authenticate with the first of its authenticators that
+// succeed
+type chainAuthenticator []Authenticator
+
+// ChainAuthenticators create an Authenticator trying each authenticator in
+// order, the error of the first one having credentials being returned when
+// none succeed
+func ChainAuthenticators(authenticators ...Authenticator) Authenticator {
+	return chainAuthenticator(authenticators)
+}
+
+func (chain chainAuthenticator) Authenticate(request *http.Request) (*Principal, error) {
+	var firstErr error
+
+	for _, authenticator := range chain {
+		principal, err := authenticator.Authenticate(request)
+		if err == nil {
+			return principal, nil
+		}
+
+		if firstErr == nil && !errors.Is(err, ErrNoCredentials) {
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return nil, ErrNoCredentials
+}
+
+// bearerToken return the token of the Authorization header of request
+func bearerToken(request *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(request.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, bearerScheme) {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+// BearerAuthenticator authenticate the requests bearing one of its static
+// tokens, the principal being named after the token
+type BearerAuthenticator struct {
+	// tokens by principal name
+	tokens map[string]string
+}
+
+// NewBearerAuthenticator create a BearerAuthenticator of tokens by principal
+// name, like the ones of GetEnvironmentStrings
+func NewBearerAuthenticator(tokens map[string]string) *BearerAuthenticator {
+	return &BearerAuthenticator{tokens: tokens}
+}
+
+// Authenticate compare the bearer token to every token in constant time
+func (authenticator *BearerAuthenticator) Authenticate(request *http.Request) (*Principal, error) {
+	token, found := bearerToken(request)
+	if !found {
+		return nil, ErrNoCredentials
+	}
+
+	var principal *Principal
+
+	for name, expected := range authenticator.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			principal = &Principal{Name: name, Method: AuthMethodBearer}
+		}
+	}
+
+	if principal == nil {
+		return nil, errors.New("invalid bearer token")
+	}
+
+	return principal, nil
+}
+
+// JWTAuthenticator authenticate the requests bearing a JWT signed by a key
+// of a JWKS, the principal being named after its subject
+type JWTAuthenticator struct {
+	keys     *jose.JSONWebKeySet
+	expected jwt.Expected
+}
+
+// NewJWTAuthenticator create a JWTAuthenticator verifying the signatures
+// with the JWKS file at jwksPath, and the issuer and audience of the tokens
+// when they are not empty
+func NewJWTAuthenticator(jwksPath, issuer, audience string) (*JWTAuthenticator, error) {
+	content, err := os.ReadFile(jwksPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	if err = json.Unmarshal(content, keys); err != nil {
+		return nil, errors.Wrapf(err, "json.Unmarshal(%q)", jwksPath)
+	}
+
+	if len(keys.Keys) == 0 {
+		return nil, errors.Errorf("no key in JWKS %q", jwksPath)
+	}
+
+	authenticator := &JWTAuthenticator{
+		keys:     keys,
+		expected: jwt.Expected{Issuer: issuer},
+	}
+
+	if audience != "" {
+		authenticator.expected.AnyAudience = jwt.Audience{audience}
+	}
+
+	return authenticator, nil
+}
+
+// Authenticate verify the signature, the expiry and the expected claims of
+// the bearer token
+func (authenticator *JWTAuthenticator) Authenticate(request *http.Request) (*Principal, error) {
+	token, found := bearerToken(request)
+	if !found {
+		return nil, ErrNoCredentials
+	}
+
+	parsed, err := jwt.ParseSigned(token, jwtSignatureAlgorithms)
+	if err != nil {
+		return nil, errors.Wrap(err, "jwt.ParseSigned")
+	}
+
+	var (
+		claims    jwt.Claims
+		allClaims map[string]any
+	)
+
+	if err = parsed.Claims(authenticator.keys, &claims, &allClaims); err != nil {
+		return nil, errors.Wrap(err, "Claims")
+	}
+
+	if claims.Expiry == nil {
+		return nil, errors.New("JWT without expiry")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("JWT without subject")
+	}
+
+	if err = claims.Validate(authenticator.expected); err != nil {
+		return nil, errors.Wrap(err, "Validate")
+	}
+
+	return &Principal{Name: claims.Subject, Method: AuthMethodJWT, Claims: allClaims}, nil
+}
+
+// MTLSAuthenticator authenticate the requests made with a verified TLS
+// client certificate, the principal being named after the common name of
+// its subject, or its first DNS name, email address or URI
+type MTLSAuthenticator struct{}
+
+// Authenticate name the principal after the leaf of the first verified chain
+func (MTLSAuthenticator) Authenticate(request *http.Request) (*Principal, error) {
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	var (
+		certificate = request.TLS.VerifiedChains[0][0]
+		name        = certificate.Subject.CommonName
+	)
+
+	switch {
+	case name != "":
+	case len(certificate.DNSNames) != 0:
+		name = certificate.DNSNames[0]
+	case len(certificate.EmailAddresses) != 0:
+		name = certificate.EmailAddresses[0]
+	case len(certificate.URIs) != 0:
+		name = certificate.URIs[0].String()
+	default:
+		return nil, errors.New("client certificate without subject")
+	}
+
+	return &Principal{Name: name, Method: AuthMethodMTLS}, nil
+}
+
+// authenticatorFromEnv create the authenticator configured by the
+// environment: static bearer tokens MCP_AUTH_TOKEN_<principal>, which can be
+// secret references (see secret.Resolve), JWT verified with the JWKS file
+// MCP_AUTH_JWKS_FILE, and TLS client certificates verified with
+// MCP_TLS_CLIENT_CA_FILE. It is nil when none is configured
+func authenticatorFromEnv() (Authenticator, error) {
+	var authenticators []Authenticator
+
+	// GetEnvironmentStrings fail when no token is defined
+	if tokens, err := GetEnvironmentStrings(envAuthTokenPrefix); err == nil {
+		for name, token := range tokens {
+			if tokens[name], err = secret.New(token).Resolve(context.Background()); err != nil {
+				return nil, errors.Wrapf(err, "token of %q", name)
+			}
+		}
+
+		authenticators = append(authenticators, NewBearerAuthenticator(tokens))
+	}
+
+	if jwksPath := os.Getenv(envAuthJWKSFile); jwksPath != "" {
+		authenticator, err := NewJWTAuthenticator(
+			jwksPath,
+			os.Getenv(envAuthJWTIssuer),
+			os.Getenv(envAuthJWTAudience),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewJWTAuthenticator")
+		}
+
+		authenticators = append(authenticators, authenticator)
+	}
+
+	if len(authenticators) == 0 && os.Getenv(envTLSClientCAFile) == "" {
+		//nolint:nilnil
+		return nil, nil
+	}
+
+	return ChainAuthenticators(append(authenticators, MTLSAuthenticator{})...), nil
+}
+
+// requireAuthentication wrap handler to reject with 401 Unauthorized the
+// requests not authenticated by authenticator, the context of the others
+// carrying their principal (see PrincipalFromContext)
+func requireAuthentication(handler http.Handler, authenticator Authenticator) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx := request.Context()
+
+		principal, err := authenticator.Authenticate(request)
+		if err != nil {
+			telemetry.LoggerFromContext(ctx).WarnContext(
+				ctx,
+				"authentication failed",
+				"error", err.Error(),
+				"remote_addr", request.RemoteAddr,
+			)
+
+			writer.Header().Set("WWW-Authenticate", bearerScheme)
+			http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		handler.ServeHTTP(writer, request.WithContext(ContextWithPrincipal(ctx, principal)))
+	})
+}
+
+// samePrincipal tell whether principal and other are the same caller
+func samePrincipal(principal, other *Principal) bool {
+	return principal != nil && other != nil && principal.Name == other.Name && principal.Method == other.Method
+}
+
+// sessionPrincipals bind the SSE sessions to the principal that opened them,
+// the messages of a session being rejected when posted by another principal
+type sessionPrincipals struct {
+	ssePath, messagePath string
+	// principals by session ID
+	principals sync.Map
+}
+
+// newSessionPrincipals create the sessionPrincipals of the SSE endpoint at
+// ssePath and of the message endpoint at messagePath
+func newSessionPrincipals(ssePath, messagePath string) *sessionPrincipals {
+	return &sessionPrincipals{ssePath: ssePath, messagePath: messagePath}
+}
+
+// bind wrap the handler of the SSE transport, which must be authenticated, to
+// record the principal of the sessions it open and reject with 403 Forbidden
+// the messages posted by another principal
+func (sessions *sessionPrincipals) bind(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var (
+			ctx       = request.Context()
+			principal = PrincipalFromContext(ctx)
+		)
+
+		switch request.URL.Path {
+		case sessions.ssePath:
+			recorder := &sessionRecorder{ResponseWriter: writer, record: func(sessionID string) {
+				sessions.principals.Store(sessionID, principal)
+			}}
+
+			defer func() {
+				if recorder.sessionID != "" {
+					sessions.principals.Delete(recorder.sessionID)
+				}
+			}()
+
+			writer = recorder
+		case sessions.messagePath:
+			owner, exists := sessions.principals.Load(request.URL.Query().Get("sessionId"))
+			if ownerPrincipal, _ := owner.(*Principal); exists && !samePrincipal(ownerPrincipal, principal) {
+				telemetry.LoggerFromContext(ctx).WarnContext(
+					ctx,
+					"message posted to the session of another principal",
+					"principal", principal.Name,
+					"remote_addr", request.RemoteAddr,
+				)
+
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+				return
+			}
+		}
+
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+// sessionRecorder is the http.ResponseWriter of an SSE connection, recording
+// the ID of its session from the endpoint event sent when it is opened
+type sessionRecorder struct {
+	http.ResponseWriter
+
+	record    func(sessionID string)
+	sessionID string
+}
+
+func (recorder *sessionRecorder) Write(data []byte) (int, error) {
+	if recorder.sessionID == "" {
+		if sessionID := endpointSessionID(string(data)); sessionID != "" {
+			// recorded before the client can post to the session
+			recorder.sessionID = sessionID
+			recorder.record(sessionID)
+		}
+	}
+
+	return recorder.ResponseWriter.Write(data) //nolint:wrapcheck
+}
+
+// Flush implements http.Flusher, which the SSE transport require
+func (recorder *sessionRecorder) Flush() {
+	if flusher, isFlusher := recorder.ResponseWriter.(http.Flusher); isFlusher {
+		flusher.Flush()
+	}
+}
+
+// endpointSessionID return the session ID of the message endpoint of an SSE
+// endpoint event, empty for other events
+func endpointSessionID(event string) string {
+	event, found := strings.CutPrefix(event, "event: endpoint\ndata: ")
+	if !found {
+		return ""
+	}
+
+	endpoint, err := url.Parse(strings.TrimSpace(event))
+	if err != nil {
+		return ""
+	}
+
+	return endpoint.Query().Get("sessionId")
+}
@@ -0,0 +1,444 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	whoamiToolName = "whoami"
+	testIssuer     = "https://issuer.test"
+	testAudience   = "mcp-tools"
+)
+
+// newWhoamiTool create a Tool that reply with the name of the principal
+func newWhoamiTool() *fakeTool {
+	return &fakeTool{
+		name: whoamiToolName,
+		exec: func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			principal := PrincipalFromContext(ctx)
+			if principal == nil {
+				return TextContentError(errors.New("unauthenticated")), nil
+			}
+
+			return mcp.NewToolResultText(principal.Method + ":" + principal.Name), nil
+		},
+	}
+}
+
+// requestWithToken create a request bearing token, if not empty
+func requestWithToken(t *testing.T, token string) *http.Request {
+	t.Helper()
+
+	request := httptest.NewRequestWithContext(t.Context(), http.MethodPost, StreamableHTTPEndpoint, nil)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return request
+}
+
+// newSigningKey create an ECDSA key identified by keyID
+func newSigningKey(t *testing.T, keyID string) jose.JSONWebKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return jose.JSONWebKey{Key: key, KeyID: keyID, Algorithm: string(jose.ES256), Use: "sig"}
+}
+
+// writeJWKS write the public keys in a JWKS file and return its path
+func writeJWKS(t *testing.T, keys ...jose.JSONWebKey) string {
+	t.Helper()
+
+	keySet := jose.JSONWebKeySet{}
+	for _, key := range keys {
+		keySet.Keys = append(keySet.Keys, key.Public())
+	}
+
+	content, err := json.Marshal(keySet)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	return path
+}
+
+// signJWT sign claims with key
+func signJWT(t *testing.T, key jose.JSONWebKey, claims any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestBearerAuthenticator(t *testing.T) {
+	authenticator := NewBearerAuthenticator(map[string]string{"ALICE": "alice-secret", "BOB": "bob-secret"})
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          *Principal
+		wantErr       error
+	}{
+		{name: "no header", wantErr: ErrNoCredentials},
+		{name: "basic scheme", authorization: "Basic QUxJQ0U6c2VjcmV0", wantErr: ErrNoCredentials},
+		{name: "empty token", authorization: "Bearer ", wantErr: ErrNoCredentials},
+		{name: "valid token", authorization: "Bearer bob-secret", want: &Principal{Name: "BOB", Method: AuthMethodBearer}},
+		{name: "case insensitive scheme", authorization: "bearer alice-secret", want: &Principal{Name: "ALICE", Method: AuthMethodBearer}},
+		{name: "invalid token", authorization: "Bearer alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := requestWithToken(t, "")
+			request.Header.Set("Authorization", tt.authorization)
+
+			got, err := authenticator.Authenticate(request)
+			if tt.want == nil {
+				require.Error(t, err)
+
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.NotErrorIs(t, err, ErrNoCredentials)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	var (
+		key           = newSigningKey(t, "key")
+		otherKey      = newSigningKey(t, "other")
+		now           = time.Now()
+		validClaims   = jwt.Claims{Subject: "alice", Issuer: testIssuer, Audience: jwt.Audience{testAudience}, Expiry: jwt.NewNumericDate(now.Add(time.Hour))}
+		expiredClaims = validClaims
+	)
+
+	expiredClaims.Expiry = jwt.NewNumericDate(now.Add(-time.Hour))
+
+	authenticator, err := NewJWTAuthenticator(writeJWKS(t, key), testIssuer, testAudience)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr error
+	}{
+		{name: "no token", wantErr: ErrNoCredentials},
+		{name: "valid", token: signJWT(t, key, validClaims), want: "alice"},
+		{name: "not a JWT", token: "alice-secret"},
+		{name: "expired", token: signJWT(t, key, expiredClaims)},
+		{name: "unknown key", token: signJWT(t, otherKey, validClaims)},
+		{name: "wrong issuer", token: signJWT(t, key, jwt.Claims{Subject: "alice", Issuer: "other", Audience: validClaims.Audience, Expiry: validClaims.Expiry})},
+		{name: "wrong audience", token: signJWT(t, key, jwt.Claims{Subject: "alice", Issuer: testIssuer, Audience: jwt.Audience{"other"}, Expiry: validClaims.Expiry})},
+		{name: "without expiry", token: signJWT(t, key, jwt.Claims{Subject: "alice", Issuer: testIssuer, Audience: validClaims.Audience})},
+		{name: "without subject", token: signJWT(t, key, jwt.Claims{Issuer: testIssuer, Audience: validClaims.Audience, Expiry: validClaims.Expiry})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := authenticator.Authenticate(requestWithToken(t, tt.token))
+			if tt.want == "" {
+				require.Error(t, err)
+
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.NotErrorIs(t, err, ErrNoCredentials)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Name)
+			assert.Equal(t, AuthMethodJWT, got.Method)
+			assert.Equal(t, testIssuer, got.Claims["iss"])
+		})
+	}
+}
+
+func TestNewJWTAuthenticatorInvalidJWKS(t *testing.T) {
+	directory := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing file"},
+		{name: "invalid JSON", content: "{"},
+		{name: "no key", content: `{"keys":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(directory, tt.name)
+			if tt.content != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			}
+
+			_, err := NewJWTAuthenticator(path, "", "")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMTLSAuthenticator(t *testing.T) {
+	tests := []struct {
+		name        string
+		state       *tls.ConnectionState
+		certificate *x509.Certificate
+		want        string
+		wantErr     error
+	}{
+		{name: "no TLS", wantErr: ErrNoCredentials},
+		{name: "no client certificate", state: &tls.ConnectionState{}, wantErr: ErrNoCredentials},
+		{name: "common name", certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}, DNSNames: []string{"alice.test"}}, want: "alice"},
+		{name: "DNS name", certificate: &x509.Certificate{DNSNames: []string{"alice.test"}}, want: "alice.test"},
+		{name: "no subject", certificate: &x509.Certificate{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := requestWithToken(t, "")
+			request.TLS = tt.state
+
+			if tt.certificate != nil {
+				request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{tt.certificate}}}
+			}
+
+			got, err := MTLSAuthenticator{}.Authenticate(request)
+			if tt.want == "" {
+				require.Error(t, err)
+
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, &Principal{Name: tt.want, Method: AuthMethodMTLS}, got)
+		})
+	}
+}
+
+func TestChainAuthenticators(t *testing.T) {
+	chain := ChainAuthenticators(MTLSAuthenticator{}, NewBearerAuthenticator(map[string]string{"ALICE": "secret"}))
+
+	principal, err := chain.Authenticate(requestWithToken(t, "secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "ALICE", principal.Name)
+
+	_, err = chain.Authenticate(requestWithToken(t, ""))
+	require.ErrorIs(t, err, ErrNoCredentials)
+
+	_, err = chain.Authenticate(requestWithToken(t, "invalid"))
+	require.ErrorContains(t, err, "invalid bearer token")
+}
+
+func TestAuthenticatorFromEnv(t *testing.T) {
+	os.Clearenv()
+
+	authenticator, err := authenticatorFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, authenticator)
+
+	t.Setenv(envAuthTokenPrefix+"_ALICE", "secret")
+
+	authenticator, err = authenticatorFromEnv()
+	require.NoError(t, err)
+
+	principal, err := authenticator.Authenticate(requestWithToken(t, "secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "ALICE", principal.Name)
+
+	// the tokens can be secret references
+	t.Setenv("BOB_TOKEN", "bob-secret")
+	t.Setenv(envAuthTokenPrefix+"_BOB", "env://BOB_TOKEN")
+
+	authenticator, err = authenticatorFromEnv()
+	require.NoError(t, err)
+
+	principal, err = authenticator.Authenticate(requestWithToken(t, "bob-secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "BOB", principal.Name)
+
+	t.Setenv(envAuthTokenPrefix+"_BOB", "env://UNDEFINED_TOKEN")
+
+	_, err = authenticatorFromEnv()
+	require.Error(t, err)
+
+	t.Setenv(envAuthTokenPrefix+"_BOB", "bob-secret")
+	t.Setenv(envAuthJWKSFile, filepath.Join(t.TempDir(), "missing.json"))
+
+	_, err = authenticatorFromEnv()
+	require.Error(t, err)
+}
+
+func TestServeAuthentication(t *testing.T) {
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newWhoamiTool()}))
+
+	baseURL := startHTTPTransport(
+		t,
+		srv,
+		TransportHTTP,
+		WithAuthenticator(NewBearerAuthenticator(map[string]string{"ALICE": "secret"})),
+	)
+
+	// the probes are not authenticated
+	assert.Equal(t, "ok\n", getBody(t, baseURL+HealthEndpoint))
+
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{name: "without token", wantErr: true},
+		{name: "invalid token", token: "invalid", wantErr: true},
+		{name: "valid token", token: "secret", want: "bearer:ALICE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := client.NewStreamableHttpClient(
+				baseURL+StreamableHTTPEndpoint,
+				transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer " + tt.token}),
+			)
+			require.NoError(t, err)
+
+			defer func() {
+				assert.NoError(t, cli.Close())
+			}()
+
+			require.NoError(t, cli.Start(t.Context()))
+
+			initRequest := mcp.InitializeRequest{}
+			initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+
+			_, err = cli.Initialize(t.Context(), initRequest)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Name = whoamiToolName
+
+			result, err := cli.CallTool(t.Context(), request)
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+			require.Len(t, result.Content, 1)
+			assert.Equal(t, tt.want, result.Content[0].(mcp.TextContent).Text)
+		})
+	}
+}
+
+func TestServeSSESessionPrincipal(t *testing.T) {
+	baseURL := startHTTPTransport(
+		t,
+		newEchoServer(t),
+		TransportSSE,
+		WithAuthenticator(NewBearerAuthenticator(map[string]string{"ALICE": "alice", "BOB": "bob"})),
+	)
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, baseURL+"/sse", nil)
+	require.NoError(t, err)
+	request.Header.Set("Authorization", "Bearer alice")
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, response.Body.Close())
+	}()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	// the endpoint event carry the message endpoint of the session
+	var endpoint string
+
+	scanner := bufio.NewScanner(response.Body)
+	for endpoint == "" && scanner.Scan() {
+		if data, found := strings.CutPrefix(scanner.Text(), "data: "); found {
+			endpoint = strings.TrimSpace(data)
+		}
+	}
+
+	require.NotEmpty(t, endpoint)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "other principal", token: "bob", wantStatus: http.StatusForbidden},
+		{name: "session principal", token: "alice", wantStatus: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := http.NewRequestWithContext(
+				t.Context(),
+				http.MethodPost,
+				baseURL+endpoint,
+				strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`),
+			)
+			require.NoError(t, err)
+			message.Header.Set("Authorization", "Bearer "+tt.token)
+
+			response, err := http.DefaultClient.Do(message)
+			require.NoError(t, err)
+			assert.NoError(t, response.Body.Close())
+			assert.Equal(t, tt.wantStatus, response.StatusCode)
+		})
+	}
+}
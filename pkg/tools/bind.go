@@ -0,0 +1,357 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+)
+
+// Keys of the `mcp` struct tag, like:
+//
+//	Limit int `json:"limit" mcp:"required,minimum=1,maximum=100,default=10"`
+const (
+	tagMCP         = "mcp"
+	tagJSON        = "json"
+	tagRequired    = "required"
+	tagDescription = "description"
+	tagTitle       = "title"
+	tagDefault     = "default"
+	tagEnum        = "enum"
+	tagMinimum     = "minimum"
+	tagMaximum     = "maximum"
+)
+
+// ArgumentsError gather every invalid argument of a tool call
+type ArgumentsError struct {
+	Errors []error
+}
+
+func (e *ArgumentsError) Error() string {
+	messages := make([]string, len(e.Errors))
+
+	for index, err := range e.Errors {
+		messages[index] = err.Error()
+	}
+
+	return "invalid arguments: " + strings.Join(messages, "; ")
+}
+
+// fieldOptions are the binding and schema options of a struct field
+type fieldOptions struct {
+	name         string
+	required     bool
+	title        string
+	description  string
+	defaultValue *string
+	enum         []string
+	minimum      *float64
+	maximum      *float64
+}
+
+// splitTag split a struct tag on its commas, except the ones escaped as `\,`
+func splitTag(tag string) []string {
+	var (
+		parts   []string
+		current strings.Builder
+	)
+
+	for index := 0; index < len(tag); index++ {
+		switch {
+		case tag[index] == '\\' && index+1 < len(tag) && tag[index+1] == ',':
+			current.WriteByte(',')
+			index++
+		case tag[index] == ',':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(tag[index])
+		}
+	}
+
+	return append(parts, current.String())
+}
+
+// parseFieldOptions read the `json` and `mcp` tags of field, nil if the
+// field is not an argument
+func parseFieldOptions(field reflect.StructField) (*fieldOptions, error) {
+	if !field.IsExported() {
+		//nolint:nilnil
+		return nil, nil
+	}
+
+	jsonName, _, _ := strings.Cut(field.Tag.Get(tagJSON), ",")
+	if jsonName == "-" {
+		//nolint:nilnil
+		return nil, nil
+	}
+
+	options := &fieldOptions{name: jsonName}
+	if len(options.name) == 0 {
+		options.name = field.Name
+	}
+
+	tag, hasTag := field.Tag.Lookup(tagMCP)
+	if !hasTag {
+		return options, nil
+	}
+
+	for _, part := range splitTag(tag) {
+		key, value, _ := strings.Cut(part, "=")
+
+		switch key {
+		case "":
+		case tagRequired:
+			options.required = true
+		case tagTitle:
+			options.title = value
+		case tagDescription:
+			options.description = value
+		case tagDefault:
+			options.defaultValue = &value
+		case tagEnum:
+			options.enum = append(options.enum, value)
+		case tagMinimum, tagMaximum:
+			limit, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "field %s: invalid %s %q", field.Name, key, value)
+			}
+
+			if key == tagMinimum {
+				options.minimum = &limit
+			} else {
+				options.maximum = &limit
+			}
+		default:
+			return nil, errors.Errorf("field %s: unknown %s tag option %q", field.Name, tagMCP, key)
+		}
+	}
+
+	return options, nil
+}
+
+// coerceString convert a string argument to the number or boolean kind of target
+func coerceString(text string, target reflect.Value) error {
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(text, 10, target.Type().Bits())
+		if err != nil {
+			return errors.Wrap(err, "strconv.ParseInt")
+		}
+
+		target.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(text, 10, target.Type().Bits())
+		if err != nil {
+			return errors.Wrap(err, "strconv.ParseUint")
+		}
+
+		target.SetUint(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(text, target.Type().Bits())
+		if err != nil {
+			return errors.Wrap(err, "strconv.ParseFloat")
+		}
+
+		target.SetFloat(number)
+	case reflect.Bool:
+		boolean, err := strconv.ParseBool(text)
+		if err != nil {
+			return errors.Wrap(err, "strconv.ParseBool")
+		}
+
+		target.SetBool(boolean)
+	default:
+		return errors.Errorf("cannot convert string to %s", target.Type())
+	}
+
+	return nil
+}
+
+// decodeArgument set target from a decoded JSON argument value
+func decodeArgument(argument any, target reflect.Value) error {
+	encoded, err := json.Marshal(argument)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	decoded := reflect.New(target.Type())
+
+	if err = json.Unmarshal(encoded, decoded.Interface()); err == nil {
+		target.Set(decoded.Elem())
+
+		return nil
+	}
+
+	// clients often send numbers and booleans as strings
+	text, isString := argument.(string)
+	if !isString {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+
+	for target.Kind() == reflect.Pointer {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+
+	return coerceString(text, target)
+}
+
+// decodeDefault set target from the default value of a tag
+func decodeDefault(defaultValue string, target reflect.Value) error {
+	for target.Kind() == reflect.Pointer {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+
+	if target.Kind() == reflect.String {
+		target.SetString(defaultValue)
+
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(defaultValue), target.Addr().Interface()); err != nil {
+		return errors.Wrapf(err, "json.Unmarshal(%q)", defaultValue)
+	}
+
+	return nil
+}
+
+// validateArgument check the enum, minimum and maximum options of a bound value
+func validateArgument(options *fieldOptions, value reflect.Value) error {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		for index := range value.Len() {
+			if err := validateArgument(options, value.Index(index)); err != nil {
+				return errors.Wrapf(err, "[%d]", index)
+			}
+		}
+
+		return nil
+	}
+
+	if len(options.enum) != 0 && !slices.Contains(options.enum, fmt.Sprint(value.Interface())) {
+		return errors.Errorf("value %v must be one of %q", value.Interface(), options.enum)
+	}
+
+	var number float64
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = value.Float()
+	default:
+		return nil
+	}
+
+	if options.minimum != nil && number < *options.minimum {
+		return errors.Errorf("value %v must be greater than or equal to %v", number, *options.minimum)
+	}
+
+	if options.maximum != nil && number > *options.maximum {
+		return errors.Errorf("value %v must be less than or equal to %v", number, *options.maximum)
+	}
+
+	return nil
+}
+
+// bindStruct set the fields of target from arguments, invalid arguments are
+// appended to invalids and an error is only returned for invalid struct tags
+func bindStruct(target reflect.Value, arguments map[string]any, invalids []error) ([]error, error) {
+	for index := range target.NumField() {
+		field := target.Type().Field(index)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && len(field.Tag.Get(tagJSON)) == 0 {
+			var err error
+			if invalids, err = bindStruct(target.Field(index), arguments, invalids); err != nil {
+				return nil, errors.Wrap(err, field.Name)
+			}
+
+			continue
+		}
+
+		options, err := parseFieldOptions(field)
+		if err != nil {
+			return nil, errors.Wrap(err, "parseFieldOptions")
+		}
+
+		if options == nil {
+			continue
+		}
+
+		argument, exists := arguments[options.name]
+
+		switch {
+		case exists && argument != nil:
+			err = decodeArgument(argument, target.Field(index))
+		case options.defaultValue != nil:
+			if err = decodeDefault(*options.defaultValue, target.Field(index)); err != nil {
+				return nil, errors.Wrapf(err, "field %s: invalid default", field.Name)
+			}
+		case options.required:
+			invalids = append(invalids, errors.Errorf("missing argument %q", options.name))
+
+			continue
+		default:
+			continue
+		}
+
+		if err == nil {
+			err = validateArgument(options, target.Field(index))
+		}
+
+		if err != nil {
+			invalids = append(invalids, errors.Wrapf(err, "argument %q", options.name))
+		}
+	}
+
+	return invalids, nil
+}
+
+// BindArguments decode the arguments of a MCP tool request into a struct T.
+// Arguments are named by the `json` tag of each field and the `mcp` tag
+// declare the options "required", "default=", "enum=" (repeated for each
+// allowed value), "minimum=" and "maximum=". Numbers and booleans sent as
+// strings are converted. All invalid arguments are reported by a single
+// *ArgumentsError, to be returned with TextContentError.
+func BindArguments[T any](request *mcp.CallToolRequest) (*T, error) {
+	var (
+		output    T
+		arguments map[string]any
+		target    = reflect.ValueOf(&output).Elem()
+	)
+
+	if target.Kind() != reflect.Struct {
+		return nil, errors.Errorf("cannot bind arguments to %s - must be a struct", target.Type())
+	}
+
+	if err := request.BindArguments(&arguments); err != nil {
+		return nil, errors.Wrap(err, "request.BindArguments")
+	}
+
+	invalids, err := bindStruct(target, arguments, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "bindStruct(%s)", target.Type())
+	}
+
+	if len(invalids) != 0 {
+		return nil, &ArgumentsError{Errors: invalids}
+	}
+
+	return &output, nil
+}
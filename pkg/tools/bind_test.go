@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindFilter struct {
+	Field  string   `json:"field"  mcp:"required"`
+	Values []string `json:"values"`
+}
+
+type bindPaging struct {
+	Limit int `json:"limit" mcp:"minimum=1,maximum=100,default=10"`
+}
+
+type bindArguments struct {
+	bindPaging
+
+	Query   string       `json:"query"   mcp:"required,description=Text to search\\, case insensitive"`
+	Order   string       `json:"order"   mcp:"enum=asc,enum=desc,default=asc"`
+	Score   float64      `json:"score"`
+	Verbose *bool        `json:"verbose"`
+	Levels  []int        `json:"levels"  mcp:"enum=1,enum=2,enum=3"`
+	Filters []bindFilter `json:"filters"`
+	Ignored string       `json:"-"`
+}
+
+func newBindRequest(arguments any) *mcp.CallToolRequest {
+	return &mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: arguments,
+		},
+	}
+}
+
+func TestBindArguments(t *testing.T) {
+	verbose := true
+
+	tests := []struct {
+		name      string
+		arguments any
+		want      *bindArguments
+		wantErrs  []string
+	}{
+		{
+			name:      "defaults",
+			arguments: map[string]any{"query": "text"},
+			want: &bindArguments{
+				bindPaging: bindPaging{Limit: 10},
+				Query:      "text",
+				Order:      "asc",
+			},
+		},
+		{
+			name: "JSON numbers, nested objects and arrays",
+			arguments: map[string]any{
+				"query":   "text",
+				"limit":   float64(42),
+				"order":   "desc",
+				"score":   0.5,
+				"verbose": true,
+				"levels":  []any{float64(1), float64(3)},
+				"filters": []any{
+					map[string]any{"field": "name", "values": []any{"a", "b"}},
+				},
+				"Ignored": "value",
+			},
+			want: &bindArguments{
+				bindPaging: bindPaging{Limit: 42},
+				Query:      "text",
+				Order:      "desc",
+				Score:      0.5,
+				Verbose:    &verbose,
+				Levels:     []int{1, 3},
+				Filters:    []bindFilter{{Field: "name", Values: []string{"a", "b"}}},
+			},
+		},
+		{
+			name:      "numbers and booleans as strings",
+			arguments: map[string]any{"query": "text", "limit": "5", "score": "1.5", "verbose": "true"},
+			want: &bindArguments{
+				bindPaging: bindPaging{Limit: 5},
+				Query:      "text",
+				Order:      "asc",
+				Score:      1.5,
+				Verbose:    &verbose,
+			},
+		},
+		{
+			name:      "raw JSON arguments",
+			arguments: json.RawMessage(`{"query":"text","limit":3}`),
+			want: &bindArguments{
+				bindPaging: bindPaging{Limit: 3},
+				Query:      "text",
+				Order:      "asc",
+			},
+		},
+		{
+			name: "all errors reported",
+			arguments: map[string]any{
+				"limit":  float64(1000),
+				"order":  "random",
+				"score":  "high",
+				"levels": []any{float64(4)},
+			},
+			wantErrs: []string{
+				`argument "limit": value 1000 must be less than or equal to 100`,
+				`missing argument "query"`,
+				`argument "order": value random must be one of ["asc" "desc"]`,
+				`argument "score"`,
+				`argument "levels": [0]: value 4 must be one of ["1" "2" "3"]`,
+			},
+		},
+		{
+			name:      "fractional number for an integer",
+			arguments: map[string]any{"query": "text", "limit": 1.5},
+			wantErrs:  []string{`argument "limit"`},
+		},
+		{
+			name:      "nested object type mismatch",
+			arguments: map[string]any{"query": "text", "filters": []any{map[string]any{"field": 1}}},
+			wantErrs:  []string{`argument "filters"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BindArguments[bindArguments](newBindRequest(tt.arguments))
+			if len(tt.wantErrs) != 0 {
+				var argumentsError *ArgumentsError
+
+				require.ErrorAs(t, err, &argumentsError)
+				require.Len(t, argumentsError.Errors, len(tt.wantErrs))
+
+				for index, wantErr := range tt.wantErrs {
+					assert.Contains(t, argumentsError.Errors[index].Error(), wantErr)
+				}
+
+				assert.Nil(t, got)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBindArgumentsTextContentError(t *testing.T) {
+	_, err := BindArguments[bindArguments](newBindRequest(map[string]any{"order": "random"}))
+	require.Error(t, err)
+
+	result := TextContentError(err)
+	require.True(t, result.IsError)
+	require.Len(t, result.Content, 1)
+
+	text, isText := mcp.AsTextContent(result.Content[0])
+	require.True(t, isText)
+	assert.Contains(t, text.Text, `missing argument`)
+	assert.Contains(t, text.Text, `must be one of`)
+}
+
+func TestBindArgumentsInvalidTarget(t *testing.T) {
+	_, err := BindArguments[string](newBindRequest(map[string]any{}))
+	require.Error(t, err)
+
+	type invalidTag struct {
+		Limit int `json:"limit" mcp:"minimum=one"`
+	}
+
+	_, err = BindArguments[invalidTag](newBindRequest(map[string]any{"limit": float64(1)}))
+	require.Error(t, err)
+
+	var argumentsError *ArgumentsError
+
+	assert.NotErrorAs(t, err, &argumentsError, "invalid tags are not argument errors")
+
+	type invalidDefault struct {
+		Limit int `json:"limit" mcp:"default=ten"`
+	}
+
+	_, err = BindArguments[invalidDefault](newBindRequest(nil))
+	require.Error(t, err)
+}
+
+func TestSplitTag(t *testing.T) {
+	assert.Equal(t, []string{"required", "description=a, b", "enum=x"}, splitTag(`required,description=a\, b,enum=x`))
+	assert.Equal(t, []string{""}, splitTag(""))
+}
@@ -0,0 +1,425 @@
+// Package config load named configurations of MCP server tools, merging a
+// YAML or JSON file, environment variables and command line flags, to be
+// selected with tools.WithConfigurationOption and tools.SelectFromConfiguration
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Validator is implemented by the configurations checking their fields once
+// loaded, like Endpoint
+type Validator interface {
+	Validate() error
+}
+
+// sources of the configurations
+type sources struct {
+	file       string
+	envFile    string
+	envPrefix  string
+	flagPrefix string
+	args       []string
+}
+
+// Option add a source to Load
+type Option func(*sources)
+
+// WithFile load the configurations from a YAML or JSON file mapping their
+// names to their fields, ignored when path is empty. Unknown fields are
+// rejected.
+func WithFile(path string) Option {
+	return func(src *sources) {
+		src.file = path
+	}
+}
+
+// WithEnv load the fields from the environment variables
+// <prefix>_<NAME>_<FIELD>, like MCP_CONFIG_PRODUCTION_URL for the field url
+// of the configuration production. The names are lower-cased and the fields
+// are upper-cased, with other characters than letters and digits replaced by
+// an underscore.
+func WithEnv(prefix string) Option {
+	return func(src *sources) {
+		src.envPrefix = prefix
+	}
+}
+
+// WithEnvFile load the fields from the KEY=VALUE lines of an env file, named
+// like the environment variables of WithEnv and overridden by them, ignored
+// when path is empty. Blank lines, comments starting with # and the export
+// keyword are ignored, and quoted values are unquoted.
+func WithEnvFile(path string) Option {
+	return func(src *sources) {
+		src.envFile = path
+	}
+}
+
+// WithFlags load the fields from the command line flags
+// --<prefix>.<name>.<field>=<value> or --<prefix>.<name>.<field> <value> of
+// args, like os.Args[1:]. Other arguments are ignored.
+func WithFlags(prefix string, args []string) Option {
+	return func(src *sources) {
+		src.flagPrefix = prefix
+		src.args = args
+	}
+}
+
+// layer is the fields of the configurations set by a source, by name
+type layer map[string]map[string]string
+
+// set the field of the configuration name
+func (values layer) set(name, field, value string) {
+	if values[name] == nil {
+		values[name] = make(map[string]string)
+	}
+
+	values[name][field] = value
+}
+
+// node return the fields of the configuration name as a YAML mapping, the
+// values being plain scalars resolved like in a file, except the ones of the
+// stringFields kept literal, like "null" or "~"
+func (values layer) node(name string, stringFields map[string]bool) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for field, value := range values[name] {
+		valueNode := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if stringFields[field] {
+			valueNode.Tag = "!!str"
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field}, valueNode)
+	}
+
+	return node
+}
+
+// Load the named configurations of type T, a struct, from the sources of
+// options. The fields are merged by precedence: the flags override the
+// environment variables, which override the env file, which override the
+// file. The names are matched in every source ignoring case and the
+// characters other than letters and digits, like "EU-West" and the
+// environment variables of eu_west, the configuration being named by the
+// first source defining it. The fields are named
+// after their yaml tag, else their lower-cased Go name, and are decoded like
+// in a file: durations like "30s", URL from strings, numbers and booleans.
+// A configuration implementing Validator is validated once merged.
+func Load[T any](options ...Option) (map[string]*T, error) {
+	src := &sources{}
+	for _, option := range options {
+		option(src)
+	}
+
+	fields, err := fieldNames(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, errors.Wrap(err, "fieldNames")
+	}
+
+	stringFields := stringFieldNames(reflect.TypeFor[T]())
+
+	configurations, err := readFile[T](src.file)
+	if err != nil {
+		return nil, errors.Wrap(err, "readFile")
+	}
+
+	envFile, err := readEnvFile(src.envFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "readEnvFile")
+	}
+
+	flags, err := readFlags(src.flagPrefix, src.args, fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "readFlags")
+	}
+
+	layers := []layer{
+		readEnv(src.envPrefix, fields, envFile),
+		readEnv(src.envPrefix, fields, os.Environ()),
+		flags,
+	}
+
+	// the names are matched like the environment variables name them
+	names := make(map[string]string, len(configurations))
+
+	for _, name := range slices.Sorted(maps.Keys(configurations)) {
+		if other, exists := names[envName(name)]; exists {
+			return nil, errors.Errorf("configurations %q and %q have the same name", other, name)
+		}
+
+		names[envName(name)] = name
+	}
+
+	for _, values := range layers {
+		for _, name := range slices.Sorted(maps.Keys(values)) {
+			merged, exists := names[envName(name)]
+			if !exists {
+				merged = name
+				names[envName(name)] = name
+			}
+
+			configuration, exists := configurations[merged]
+			if !exists {
+				configuration = new(T)
+				configurations[merged] = configuration
+			}
+
+			if err = values.node(name, stringFields).Decode(configuration); err != nil {
+				return nil, errors.Wrapf(err, "configuration %q", name)
+			}
+		}
+	}
+
+	for name, configuration := range configurations {
+		if validator, isValidator := any(configuration).(Validator); isValidator {
+			if err = validator.Validate(); err != nil {
+				return nil, errors.Wrapf(err, "configuration %q", name)
+			}
+		}
+	}
+
+	return configurations, nil
+}
+
+// fieldNames return the names of the fields of a struct type
+func fieldNames(structType reflect.Type) ([]string, error) {
+	if structType.Kind() != reflect.Struct {
+		return nil, errors.Errorf("%s is not a struct", structType)
+	}
+
+	var names []string
+
+	for index := range structType.NumField() {
+		field := structType.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		if name, named := fieldName(field); named {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// fieldName return the name of an exported field of a struct, after its yaml
+// tag else its lower-cased Go name, unless it is ignored
+func fieldName(field reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return strings.ToLower(field.Name), true
+	default:
+		return name, true
+	}
+}
+
+// stringFieldNames return the names of the fields of a struct type decoded
+// from strings: the strings and the encoding.TextUnmarshaler, like URL
+func stringFieldNames(structType reflect.Type) map[string]bool {
+	textUnmarshaler := reflect.TypeFor[encoding.TextUnmarshaler]()
+	names := make(map[string]bool)
+
+	for index := range structType.NumField() {
+		field := structType.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() != reflect.String && !reflect.PointerTo(fieldType).Implements(textUnmarshaler) {
+			continue
+		}
+
+		if name, named := fieldName(field); named {
+			names[name] = true
+		}
+	}
+
+	return names
+}
+
+// readFile decode the configurations of a file, none when path is empty
+func readFile[T any](path string) (map[string]*T, error) {
+	configurations := make(map[string]*T)
+
+	if path == "" {
+		return configurations, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	// an empty file has no configuration
+	if err = decoder.Decode(&configurations); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrapf(err, "yaml.Decode(%q)", path)
+	}
+
+	// a configuration without field is decoded as nil
+	for name, configuration := range configurations {
+		if configuration == nil {
+			configurations[name] = new(T)
+		}
+	}
+
+	return configurations, nil
+}
+
+// envName return the environment variable part of a field or a name
+func envName(field string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, field))
+}
+
+// readEnvFile return the KEY=VALUE lines of an env file, like os.Environ,
+// none when path is empty
+func readEnvFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+
+	var environ []string
+
+	for index, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			return nil, errors.Errorf("%s:%d: missing =", path, index+1)
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, errors.Wrapf(err, "%s:%d: strconv.Unquote", path, index+1)
+			}
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+
+		environ = append(environ, strings.TrimSpace(key)+"="+value)
+	}
+
+	return environ, nil
+}
+
+// readEnv read the fields of the KEY=VALUE environment variables
+// <prefix>_<NAME>_<FIELD> of environ, none when prefix is empty
+func readEnv(prefix string, fields []string, environ []string) layer {
+	values := make(layer)
+
+	if prefix == "" {
+		return values
+	}
+
+	for _, env := range environ {
+		key, value, _ := strings.Cut(env, "=")
+
+		rest, found := strings.CutPrefix(key, prefix+"_")
+		if !found {
+			continue
+		}
+
+		// the longest field wins, the name being what precede it
+		var matched string
+
+		for _, field := range fields {
+			suffix := "_" + envName(field)
+			if strings.HasSuffix(rest, suffix) && len(rest) > len(suffix) && len(field) > len(matched) {
+				matched = field
+			}
+		}
+
+		if matched != "" {
+			name := strings.ToLower(strings.TrimSuffix(rest, "_"+envName(matched)))
+			values.set(name, matched, value)
+		}
+	}
+
+	return values
+}
+
+// readFlags read the fields of the flags --<prefix>.<name>.<field>, none when
+// prefix is empty
+func readFlags(prefix string, args []string, fields []string) (layer, error) {
+	values := make(layer)
+
+	if prefix == "" {
+		return values, nil
+	}
+
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		flag, found := strings.CutPrefix(strings.TrimLeft(arg, "-"), prefix+".")
+		if !found {
+			continue
+		}
+
+		flag, value, hasValue := strings.Cut(flag, "=")
+		if !hasValue {
+			if index+1 >= len(args) {
+				return nil, errors.Errorf("missing value of flag %q", arg)
+			}
+
+			index++
+			value = args[index]
+		}
+
+		separator := strings.LastIndex(flag, ".")
+		if separator <= 0 {
+			return nil, errors.Errorf("flag %q is not --%s.<name>.<field>", arg, prefix)
+		}
+
+		name, field := flag[:separator], flag[separator+1:]
+		if !slices.Contains(fields, field) {
+			return nil, errors.Errorf("unknown field %q of flag %q", field, arg)
+		}
+
+		values.set(name, field, value)
+	}
+
+	return values, nil
+}
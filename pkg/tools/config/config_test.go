@@ -0,0 +1,364 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+)
+
+// testConfiguration has fields of every kind
+type testConfiguration struct {
+	Address    string        `yaml:"address"`
+	Port       int           `yaml:"port"`
+	Enabled    bool          `yaml:"enabled"`
+	Timeout    time.Duration `yaml:"timeout"`
+	MaxRetries int           `yaml:"max_retries"`
+	Ignored    string        `yaml:"-"`
+	Name       string
+}
+
+// writeFile write content to a file and return its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	var (
+		yamlFile = writeFile(t, "config.yaml", `
+production:
+  address: prod.example.com
+  port: 443
+  timeout: 30s
+staging:
+  address: staging.example.com
+empty:
+`)
+		jsonFile = writeFile(t, "config.json", `{"production": {"address": "prod.example.com", "port": 443}}`)
+	)
+
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		want    map[string]*testConfiguration
+		wantErr bool
+	}{
+		{
+			name: "no source",
+			want: map[string]*testConfiguration{},
+		},
+		{
+			name: "yaml file",
+			file: yamlFile,
+			want: map[string]*testConfiguration{
+				"production": {Address: "prod.example.com", Port: 443, Timeout: 30 * time.Second},
+				"staging":    {Address: "staging.example.com"},
+				"empty":      {},
+			},
+		},
+		{
+			name: "json file",
+			file: jsonFile,
+			want: map[string]*testConfiguration{
+				"production": {Address: "prod.example.com", Port: 443},
+			},
+		},
+		{
+			name: "environment",
+			env: map[string]string{
+				"TEST_CONFIG_PRODUCTION_ADDRESS":     "prod.example.com",
+				"TEST_CONFIG_PRODUCTION_PORT":        "443",
+				"TEST_CONFIG_PRODUCTION_ENABLED":     "true",
+				"TEST_CONFIG_EU_WEST_MAX_RETRIES":    "3",
+				"TEST_CONFIG_EU_WEST_NAME":           "eu=west",
+				"TEST_CONFIG_UNKNOWN":                "ignored",
+				"TEST_CONFIG_PRODUCTION_IGNORED":     "ignored",
+				"OTHER_CONFIG_PRODUCTION_ADDRESS":    "ignored",
+				"TEST_CONFIGURATION_STAGING_ADDRESS": "ignored",
+			},
+			want: map[string]*testConfiguration{
+				"production": {Address: "prod.example.com", Port: 443, Enabled: true},
+				"eu_west":    {MaxRetries: 3, Name: "eu=west"},
+			},
+		},
+		{
+			name: "flags",
+			args: []string{
+				"serve",
+				"--config.production.address=prod.example.com",
+				"-config.production.timeout", "1m",
+				"--verbose",
+				"--config.eu.west.port=8080",
+			},
+			want: map[string]*testConfiguration{
+				"production": {Address: "prod.example.com", Timeout: time.Minute},
+				"eu.west":    {Port: 8080},
+			},
+		},
+		{
+			name: "precedence",
+			file: yamlFile,
+			env: map[string]string{
+				"TEST_CONFIG_PRODUCTION_ADDRESS": "env.example.com",
+				"TEST_CONFIG_PRODUCTION_PORT":    "8443",
+				"TEST_CONFIG_DEV_ADDRESS":        "localhost",
+			},
+			args: []string{"--config.production.address", "flag.example.com"},
+			want: map[string]*testConfiguration{
+				"production": {Address: "flag.example.com", Port: 8443, Timeout: 30 * time.Second},
+				"staging":    {Address: "staging.example.com"},
+				"empty":      {},
+				"dev":        {Address: "localhost"},
+			},
+		},
+		{
+			name: "names across sources",
+			file: writeFile(t, "names.yaml", "EU-West:\n  address: eu.example.com\n"),
+			env:  map[string]string{"TEST_CONFIG_EU_WEST_PORT": "8080"},
+			args: []string{"--config.eu_west.enabled=true", "--config.Dev.port=80", "--config.dev.enabled=true"},
+			want: map[string]*testConfiguration{
+				"EU-West": {Address: "eu.example.com", Port: 8080, Enabled: true},
+				"Dev":     {Port: 80, Enabled: true},
+			},
+		},
+		{
+			name:    "same names in file",
+			file:    writeFile(t, "same.yaml", "eu-west:\n  port: 1\nEU_WEST:\n  port: 2\n"),
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			file:    filepath.Join(t.TempDir(), "missing.yaml"),
+			wantErr: true,
+		},
+		{
+			name:    "unknown field in file",
+			file:    writeFile(t, "unknown.yaml", "production:\n  unknown: value\n"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid environment value",
+			env:     map[string]string{"TEST_CONFIG_PRODUCTION_PORT": "https"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag field",
+			args:    []string{"--config.production.unknown=value"},
+			wantErr: true,
+		},
+		{
+			name:    "flag without field",
+			args:    []string{"--config.production=value"},
+			wantErr: true,
+		},
+		{
+			name:    "flag without value",
+			args:    []string{"--config.production.port"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := Load[testConfiguration](
+				WithFile(tt.file),
+				WithEnv("TEST_CONFIG"),
+				WithFlags("config", tt.args),
+			)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadNotStruct(t *testing.T) {
+	_, err := Load[string]()
+	require.Error(t, err)
+}
+
+func TestLoadEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    *Endpoint
+		wantErr bool
+	}{
+		{
+			name: "complete",
+			content: `
+production:
+  url: https://api.example.com:8443/v1
+  username: user
+  password: secret
+  timeout: 10s
+`,
+			env: map[string]string{"MCP_CONFIG_PRODUCTION_TOKEN": "token"},
+			want: &Endpoint{
+				URL:      URL{URL: *mustParseURL(t, "https://api.example.com:8443/v1")},
+				Username: "user",
+				Password: secret.New("secret"),
+				Token:    secret.New("token"),
+				Timeout:  10 * time.Second,
+			},
+		},
+		{
+			name:    "literal env values",
+			content: "production:\n  url: https://api.example.com\n",
+			env: map[string]string{
+				"MCP_CONFIG_PRODUCTION_USERNAME": "null",
+				"MCP_CONFIG_PRODUCTION_PASSWORD": "~",
+			},
+			want: &Endpoint{
+				URL:      URL{URL: *mustParseURL(t, "https://api.example.com")},
+				Username: "null",
+				Password: secret.New("~"),
+			},
+		},
+		{
+			name:    "missing url",
+			content: "production:\n  token: token\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid url",
+			content: "production:\n  url: \"http://[::1\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout",
+			content: "production:\n  url: https://api.example.com\n  timeout: -1s\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := Load[Endpoint](WithFile(writeFile(t, "config.yaml", tt.content)), WithEnv("MCP_CONFIG"))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Contains(t, got, "production")
+			assert.Equal(t, tt.want, got["production"])
+		})
+	}
+}
+
+func TestURLText(t *testing.T) {
+	parsed := URL{}
+	require.NoError(t, parsed.UnmarshalText([]byte("https://api.example.com/v1")))
+	assert.Equal(t, "api.example.com", parsed.Host)
+
+	text, err := parsed.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/v1", string(text))
+}
+
+// mustParseURL parse a valid URL
+func mustParseURL(t *testing.T, value string) *url.URL {
+	t.Helper()
+
+	parsed, err := url.Parse(value)
+	require.NoError(t, err)
+
+	return parsed
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    map[string]*testConfiguration
+		wantErr bool
+	}{
+		{
+			name: "values",
+			content: `
+# production backend
+TEST_CONFIG_PRODUCTION_ADDRESS=prod.example.com
+export TEST_CONFIG_PRODUCTION_PORT = 443
+TEST_CONFIG_STAGING_NAME="staging \"eu\""
+TEST_CONFIG_STAGING_ADDRESS='staging.example.com'
+OTHER=ignored
+`,
+			want: map[string]*testConfiguration{
+				"production": {Address: "prod.example.com", Port: 443},
+				"staging":    {Address: "staging.example.com", Name: `staging "eu"`},
+			},
+		},
+		{
+			name:    "overridden by the environment",
+			content: "TEST_CONFIG_PRODUCTION_ADDRESS=file.example.com\nTEST_CONFIG_PRODUCTION_PORT=443\n",
+			env:     map[string]string{"TEST_CONFIG_PRODUCTION_ADDRESS": "env.example.com"},
+			want: map[string]*testConfiguration{
+				"production": {Address: "env.example.com", Port: 443},
+			},
+		},
+		{
+			name:    "missing equal",
+			content: "TEST_CONFIG_PRODUCTION_ADDRESS\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid quoting",
+			content: `TEST_CONFIG_PRODUCTION_ADDRESS="\q"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := Load[testConfiguration](
+				WithEnvFile(writeFile(t, "config.env", tt.content)),
+				WithEnv("TEST_CONFIG"),
+			)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
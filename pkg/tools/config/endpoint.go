@@ -0,0 +1,61 @@
+package config
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+)
+
+// URL is a url.URL decoded from a string
+type URL struct {
+	url.URL
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (parsed *URL) UnmarshalText(text []byte) error {
+	value, err := url.Parse(string(text))
+	if err != nil {
+		return errors.Wrap(err, "url.Parse")
+	}
+
+	parsed.URL = *value
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (parsed URL) MarshalText() ([]byte, error) {
+	return []byte(parsed.String()), nil
+}
+
+// Endpoint is the configuration of a remote service, the URL being required
+// and the credentials optional. The credentials can be secret references,
+// resolved when the endpoint is selected (see secret.Resolve):
+//
+//	production:
+//	  url: https://api.example.com
+//	  token: file:///run/secrets/api-token
+//	  timeout: 30s
+type Endpoint struct {
+	URL      URL           `json:"url"                yaml:"url"`
+	Username string        `json:"username,omitempty" yaml:"username"`
+	Password secret.Secret `json:"password,omitzero"  yaml:"password"`
+	Token    secret.Secret `json:"token,omitzero"     yaml:"token"`
+	Timeout  time.Duration `json:"timeout,omitempty"  yaml:"timeout"`
+}
+
+// Validate implements Validator
+func (endpoint *Endpoint) Validate() error {
+	if endpoint.URL.Host == "" {
+		return errors.Errorf("url %q without host", endpoint.URL.String())
+	}
+
+	if endpoint.Timeout < 0 {
+		return errors.Errorf("negative timeout %s", endpoint.Timeout)
+	}
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AllConfigurations select all the configurations of a fan-out call
+	AllConfigurations = "*"
+	// propertyConfigurations is the structured content property of the
+	// results of a fan-out call
+	propertyConfigurations = "configurations"
+	// defaultFanOutConcurrency is how many configurations a fan-out call run
+	// at once when the concurrency is not given
+	defaultFanOutConcurrency = 4
+)
+
+// ConfigurationResult is the result of a tool called with one of the
+// configurations of a fan-out call, in the structured content of the merged
+// result by configuration name
+type ConfigurationResult struct {
+	// Result is the structured content of the call, if any
+	Result any `json:"result,omitempty"`
+	// Error is the message of the call when it failed
+	Error string `json:"error,omitempty"`
+}
+
+// fanOutTool is a Tool called with several configurations at once
+type fanOutTool struct {
+	Tool
+
+	concurrency int
+	// names of the configurations selected by AllConfigurations, from the
+	// definition of the tool
+	names atomic.Pointer[[]string]
+}
+
+// FanOutMiddleware let a tool be called with an array of configurations, or
+// AllConfigurations, running at most concurrency of them at once. Place it
+// before the middlewares reading the configuration, like
+// AuthorizationMiddleware, so that they see each one.
+func FanOutMiddleware(concurrency int) Middleware {
+	if concurrency <= 0 {
+		concurrency = defaultFanOutConcurrency
+	}
+
+	return func(tool Tool) Tool {
+		return &fanOutTool{
+			Tool:        tool,
+			concurrency: concurrency,
+		}
+	}
+}
+
+// New implements Tool, accepting several configurations in the input schema
+// and their results in the output schema
+func (tool *fanOutTool) New() (*mcp.Tool, error) {
+	instance, err := tool.Tool.New()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	// a tool without a configuration enum is unchanged
+	property, isMap := instance.InputSchema.Properties[argumentConfiguration].(map[string]any)
+	if !isMap {
+		return instance, nil
+	}
+
+	names, isStrings := property["enum"].([]string)
+	if !isStrings {
+		return instance, nil
+	}
+
+	tool.names.Store(&names)
+
+	instance.InputSchema.Properties = maps.Clone(instance.InputSchema.Properties)
+	instance.InputSchema.Properties[argumentConfiguration] = fanOutProperty(property, names)
+
+	if instance.OutputSchema.Type != "" {
+		instance.OutputSchema = fanOutOutputSchema(instance.OutputSchema)
+	}
+
+	return instance, nil
+}
+
+// fanOutProperty return the configuration property accepting a name, an
+// array of names or AllConfigurations
+func fanOutProperty(property map[string]any, names []string) map[string]any {
+	var (
+		single = maps.Clone(property)
+		result = map[string]any{}
+	)
+
+	for _, key := range []string{"title", "description", "default"} {
+		if value, exists := single[key]; exists {
+			result[key] = value
+			delete(single, key)
+		}
+	}
+
+	description := fmt.Sprintf("Several configurations can be given as an array, or %q for all of them",
+		AllConfigurations)
+	if previous, _ := result["description"].(string); previous != "" {
+		description = strings.TrimSuffix(previous, ".") + ". " + description
+	}
+
+	result["description"] = description
+	result["anyOf"] = []any{
+		single,
+		map[string]any{"type": "string", "const": AllConfigurations, "title": "All configurations"},
+		map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string", "enum": names},
+			"minItems":    1,
+			"uniqueItems": true,
+		},
+	}
+
+	return result
+}
+
+// fanOutOutputSchema return schema with the configurations property of the
+// results of a fan-out call, the other properties being optional as they are
+// not set by such a call
+func fanOutOutputSchema(schema mcp.ToolOutputSchema) mcp.ToolOutputSchema {
+	result := map[string]any{
+		"type":       schema.Type,
+		"properties": schema.Properties,
+	}
+
+	if len(schema.Required) > 0 {
+		result["required"] = schema.Required
+	}
+
+	properties := maps.Clone(schema.Properties)
+	if properties == nil {
+		properties = make(map[string]any)
+	}
+
+	properties[propertyConfigurations] = map[string]any{
+		"type":        "object",
+		"description": "The results by configuration name of a call with several configurations",
+		"additionalProperties": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"result": result,
+				"error":  map[string]any{"type": "string"},
+			},
+		},
+	}
+
+	schema.Properties = properties
+	schema.Required = nil
+
+	return schema
+}
+
+// Exec implements Tool, calling the tool with each configuration of a
+// fan-out call
+func (tool *fanOutTool) Exec(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	names, fanOut, err := tool.selectConfigurations(&request)
+	if err != nil {
+		return TextContentError(err), nil
+	}
+
+	if !fanOut {
+		return tool.Tool.Exec(ctx, request)
+	}
+
+	var (
+		results = make([]*mcp.CallToolResult, len(names))
+		indexes = make(chan int)
+		wg      sync.WaitGroup
+	)
+
+	for range min(tool.concurrency, len(names)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range indexes {
+				results[index] = tool.execConfiguration(ctx, request, names[index])
+			}
+		}()
+	}
+
+	for index := range names {
+		indexes <- index
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	return mergeResults(names, results), nil
+}
+
+// selectConfigurations return the configuration names of a fan-out call, and
+// false when request select a single configuration
+func (tool *fanOutTool) selectConfigurations(request *mcp.CallToolRequest) ([]string, bool, error) {
+	switch value := request.GetArguments()[argumentConfiguration].(type) {
+	case string:
+		if value != AllConfigurations {
+			return nil, false, nil
+		}
+
+		names := tool.names.Load()
+		if names == nil || len(*names) == 0 {
+			return nil, false, errors.New("no configuration to select")
+		}
+
+		return *names, true, nil
+	case []any:
+		names := make([]string, 0, len(value))
+
+		for index, item := range value {
+			name, isString := item.(string)
+			if !isString {
+				return nil, false, errors.Errorf("invalid type %T of argument %s[%d]", item, argumentConfiguration, index)
+			}
+
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+
+		if len(names) == 0 {
+			return nil, false, errors.Errorf("empty argument %q", argumentConfiguration)
+		}
+
+		return names, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// requestConfigurations return the configurations selected by request: none,
+// one, or the names of a fan-out call, AllConfigurations included
+func requestConfigurations(request *mcp.CallToolRequest) []string {
+	switch value := request.GetArguments()[argumentConfiguration].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		configurations := make([]string, 0, len(value))
+
+		for _, item := range value {
+			if name, isString := item.(string); isString {
+				configurations = append(configurations, name)
+			}
+		}
+
+		return configurations
+	default:
+		return nil
+	}
+}
+
+// execConfiguration call the tool with the configuration name, turning an
+// error or a panic into an error result, as RecoveryMiddleware can not
+// recover the goroutines of the calls
+//
+//nolint:nonamedreturns
+func (tool *fanOutTool) execConfiguration(
+	ctx context.Context,
+	request mcp.CallToolRequest,
+	name string,
+) (result *mcp.CallToolResult) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = TextContentError(errors.Errorf("tool %q panicked with configuration %q: %v",
+				tool.Name(), name, recovered))
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return TextContentError(errors.Wrap(err, "context"))
+	}
+
+	arguments := maps.Clone(request.GetArguments())
+	arguments[argumentConfiguration] = name
+	request.Params.Arguments = arguments
+
+	result, err := tool.Tool.Exec(ctx, request)
+	if err != nil {
+		return TextContentError(err)
+	}
+
+	if result == nil {
+		return &mcp.CallToolResult{}
+	}
+
+	return result
+}
+
+// mergeResults merge the results of the configurations names into a result
+// with a section and a ConfigurationResult by configuration, which is an
+// error when they all are
+func mergeResults(names []string, results []*mcp.CallToolResult) *mcp.CallToolResult {
+	var (
+		merged         = &mcp.CallToolResult{IsError: true}
+		configurations = make(map[string]ConfigurationResult, len(names))
+	)
+
+	for index, name := range names {
+		result := results[index]
+
+		merged.Content = append(merged.Content, mcp.NewTextContent(fmt.Sprintf("Configuration %q:", name)))
+		merged.Content = append(merged.Content, result.Content...)
+
+		if result.IsError {
+			configurations[name] = ConfigurationResult{Error: resultErrorMessage(result)}
+		} else {
+			configurations[name] = ConfigurationResult{Result: result.StructuredContent}
+			merged.IsError = false
+		}
+	}
+
+	merged.StructuredContent = map[string]any{propertyConfigurations: configurations}
+
+	return merged
+}
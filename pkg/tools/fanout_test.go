@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type regionOutput struct {
+	Region string `json:"region" jsonschema:"required"`
+}
+
+// newRegionTool create a Tool replying with the region of the selected
+// configuration, failing for the configurations without one
+func newRegionTool() *fakeTool {
+	regions := map[string]*string{
+		"production": new(string),
+		"staging":    new(string),
+		"broken":     nil,
+	}
+
+	*regions["production"] = "eu-west-1"
+	*regions["staging"] = "us-east-1"
+
+	return &fakeTool{
+		name:    "region",
+		options: withOptions(WithConfigurationOption(regions), mcp.WithOutputSchema[regionOutput]()),
+		exec: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			region, err := SelectFromConfigurationContext(ctx, regions, &request)
+			if err == nil && region == nil {
+				err = errors.Errorf("no region for %q", request.GetArguments()[argumentConfiguration])
+			}
+
+			if err != nil {
+				return TextContentError(err), nil
+			}
+
+			return mcp.NewToolResultStructured(regionOutput{Region: *region}, *region), nil
+		},
+	}
+}
+
+func newRegionRequest(configuration any) mcp.CallToolRequest {
+	return mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "region",
+			Arguments: map[string]any{argumentConfiguration: configuration},
+		},
+	}
+}
+
+func TestFanOutMiddlewareNew(t *testing.T) {
+	instance, err := Chain(newRegionTool(), FanOutMiddleware(0)).New()
+	require.NoError(t, err)
+
+	property, isMap := instance.InputSchema.Properties[argumentConfiguration].(map[string]any)
+	require.True(t, isMap)
+	assert.Equal(t, "Configuration name", property["title"])
+	assert.Contains(t, property["description"], `or "*" for all of them`)
+	assert.NotContains(t, property, "enum")
+
+	anyOf, isSlice := property["anyOf"].([]any)
+	require.True(t, isSlice)
+	require.Len(t, anyOf, 3)
+	assert.Equal(t, []string{"broken", "production", "staging"}, anyOf[0].(map[string]any)["enum"])
+	assert.Equal(t, AllConfigurations, anyOf[1].(map[string]any)["const"])
+	assert.Equal(t, "array", anyOf[2].(map[string]any)["type"])
+	assert.Equal(t, []string{"broken", "production", "staging"}, configurationNames(instance))
+
+	assert.Contains(t, instance.OutputSchema.Properties, "region")
+	assert.Contains(t, instance.OutputSchema.Properties, propertyConfigurations)
+	assert.Empty(t, instance.OutputSchema.Required)
+
+	// a tool without configuration is unchanged
+	instance, err = Chain(Func("sum", "Add numbers", sum), FanOutMiddleware(0)).New()
+	require.NoError(t, err)
+	assert.NotContains(t, instance.InputSchema.Properties, argumentConfiguration)
+	assert.NotContains(t, instance.OutputSchema.Properties, propertyConfigurations)
+}
+
+func TestFanOutMiddlewareExec(t *testing.T) {
+	tests := []struct {
+		name           string
+		configuration  any
+		wantErr        bool
+		wantContent    []string
+		wantStructured string
+	}{
+		{
+			name:          "single configuration",
+			configuration: "staging",
+			wantContent:   []string{"us-east-1"},
+		},
+		{
+			name:          "all configurations",
+			configuration: AllConfigurations,
+			wantContent: []string{
+				`Configuration "broken":`, `Error: "no region for \"broken\""`,
+				`Configuration "production":`, "eu-west-1",
+				`Configuration "staging":`, "us-east-1",
+			},
+			wantStructured: `{"configurations": {
+				"broken": {"error": "Error: \"no region for \\\"broken\\\"\""},
+				"production": {"result": {"region": "eu-west-1"}},
+				"staging": {"result": {"region": "us-east-1"}}
+			}}`,
+		},
+		{
+			name:          "selected configurations",
+			configuration: []any{"staging", "production", "staging"},
+			wantContent: []string{
+				`Configuration "staging":`, "us-east-1",
+				`Configuration "production":`, "eu-west-1",
+			},
+			wantStructured: `{"configurations": {
+				"production": {"result": {"region": "eu-west-1"}},
+				"staging": {"result": {"region": "us-east-1"}}
+			}}`,
+		},
+		{
+			name:          "all failed",
+			configuration: []any{"broken"},
+			wantErr:       true,
+			wantContent:   []string{`Configuration "broken":`, `Error: "no region for \"broken\""`},
+		},
+		{
+			name:          "empty array",
+			configuration: []any{},
+			wantErr:       true,
+			wantContent:   []string{`Error: "empty argument \"configuration\""`},
+		},
+		{
+			name:          "invalid item",
+			configuration: []any{"staging", 1},
+			wantErr:       true,
+			wantContent:   []string{`Error: "invalid type int of argument configuration[1]"`},
+		},
+	}
+
+	tool := Chain(newRegionTool(), FanOutMiddleware(2))
+
+	_, err := tool.New()
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tool.Exec(t.Context(), newRegionRequest(tt.configuration))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantErr, result.IsError)
+
+			texts := make([]string, len(result.Content))
+			for index, content := range result.Content {
+				texts[index] = content.(mcp.TextContent).Text
+			}
+
+			assert.Equal(t, tt.wantContent, texts)
+
+			if tt.wantStructured != "" {
+				encoded, err := json.Marshal(result.StructuredContent)
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.wantStructured, string(encoded))
+			}
+		})
+	}
+}
+
+func TestFanOutMiddlewareConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+
+	tool := Chain(&fakeTool{
+		options: withOptions(WithConfigurationOption(map[string]*int{"a": nil, "b": nil, "c": nil, "d": nil, "e": nil})),
+		exec: func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+
+			for previous := maxRunning.Load(); current > previous; previous = maxRunning.Load() {
+				if maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+
+			return mcp.NewToolResultText("done"), nil
+		},
+	}, FanOutMiddleware(2))
+
+	_, err := tool.New()
+	require.NoError(t, err)
+
+	result, err := tool.Exec(t.Context(), newRegionRequest(AllConfigurations))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Len(t, result.Content, 10)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestFanOutMiddlewareOverMCP(t *testing.T) {
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newRegionTool()}, FanOutMiddleware(0)))
+
+	cli, err := client.NewInProcessClient(srv)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	initializeClient(t, cli)
+
+	result, err := cli.CallTool(t.Context(), newRegionRequest([]string{"production", "staging"}))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	encoded, err := json.Marshal(result.StructuredContent)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"configurations": {
+		"production": {"result": {"region": "eu-west-1"}},
+		"staging": {"result": {"region": "us-east-1"}}
+	}}`, string(encoded))
+}
+
+func TestFanOutMiddlewarePanic(t *testing.T) {
+	tool := Chain(&fakeTool{
+		options: withOptions(WithConfigurationOption(map[string]*int{"ok": nil, "panic": nil})),
+		exec: func(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if request.GetArguments()[argumentConfiguration] == "panic" {
+				panic("boom")
+			}
+
+			return mcp.NewToolResultText("done"), nil
+		},
+	}, FanOutMiddleware(0))
+
+	_, err := tool.New()
+	require.NoError(t, err)
+
+	result, err := tool.Exec(t.Context(), newRegionRequest(AllConfigurations))
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	configurations, isMap := result.StructuredContent.(map[string]any)[propertyConfigurations].(map[string]ConfigurationResult)
+	require.True(t, isMap)
+	assert.Empty(t, configurations["ok"].Error)
+	assert.Contains(t, configurations["panic"].Error, `panicked with configuration \"panic\": boom`)
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+)
+
+// FuncTool is a Tool implemented by a typed function, created by Func
+type FuncTool[In, Out any] struct {
+	name        string
+	description string
+	function    func(context.Context, In) (Out, error)
+	template    *template.Template
+	options     []mcp.ToolOption
+}
+
+// Func create a Tool that bind its arguments to In (see BindArguments), call
+// function and return its Out result both as text and, if Out is a struct or
+// a pointer to one, as structured content matching an output schema generated
+// from Out, a nil pointer being an error.
+// The text is rendered by the template set by WithTemplate, else by the
+// String method of Out if it is a fmt.Stringer, else as indented JSON.
+func Func[In, Out any](name, description string, function func(context.Context, In) (Out, error)) *FuncTool[In, Out] {
+	return &FuncTool[In, Out]{
+		name:        name,
+		description: description,
+		function:    function,
+	}
+}
+
+// WithTemplate render the text content of results with tpl
+func (tool *FuncTool[In, Out]) WithTemplate(tpl *template.Template) *FuncTool[In, Out] {
+	tool.template = tpl
+
+	return tool
+}
+
+// WithOptions add options to the tool definition, like WithConfigurationOption
+func (tool *FuncTool[In, Out]) WithOptions(options ...mcp.ToolOption) *FuncTool[In, Out] {
+	tool.options = append(tool.options, options...)
+
+	return tool
+}
+
+// Name implements Tool
+func (tool *FuncTool[In, Out]) Name() string {
+	return tool.name
+}
+
+// New implements Tool
+func (tool *FuncTool[In, Out]) New() (*mcp.Tool, error) {
+	options := append([]mcp.ToolOption{mcp.WithDescription(tool.description)}, tool.options...)
+
+	instance, err := NewTypedTool[In, Out](tool.name, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewTypedTool")
+	}
+
+	return instance, nil
+}
+
+// hasStructuredContent tell if typ is a struct or a pointer to a struct,
+// which is described by the output schema
+func hasStructuredContent(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return typ.Kind() == reflect.Struct
+}
+
+// isNilPointer tell if output is a nil pointer, or a pointer to one
+func isNilPointer(output any) bool {
+	value := reflect.ValueOf(output)
+
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return true
+		}
+
+		value = value.Elem()
+	}
+
+	return false
+}
+
+// render the text content of a result
+func (tool *FuncTool[In, Out]) render(output Out) (string, error) {
+	if tool.template != nil {
+		buf := bytes.NewBuffer(nil)
+		if err := tool.template.Execute(buf, output); err != nil {
+			return "", errors.Wrap(err, "template.Execute")
+		}
+
+		return buf.String(), nil
+	}
+
+	if stringer, isStringer := any(output).(fmt.Stringer); isStringer {
+		return stringer.String(), nil
+	}
+
+	encoded, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "json.MarshalIndent")
+	}
+
+	return string(encoded), nil
+}
+
+// Exec implements Tool
+func (tool *FuncTool[In, Out]) Exec(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input, err := BindArguments[In](&request)
+	if err != nil {
+		return TextContentError(errors.Wrap(err, BindArgumentsError)), nil
+	}
+
+	output, err := tool.function(ctx, *input)
+	if err != nil {
+		return TextContentError(err), nil
+	}
+
+	// structured content must be an object, described by the output schema
+	structured := hasStructuredContent(reflect.TypeFor[Out]())
+	if structured && isNilPointer(output) {
+		return TextContentError(errors.Errorf("tool %q returned no output", tool.name)), nil
+	}
+
+	text, err := tool.render(output)
+	if err != nil {
+		return TextContentError(errors.Wrap(err, "render")), nil
+	}
+
+	result := mcp.NewToolResultText(text)
+
+	if structured {
+		result.StructuredContent = output
+	}
+
+	return result, nil
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"text/template"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sumInput struct {
+	Numbers []float64 `json:"numbers" mcp:"required,description=Numbers to add"`
+}
+
+type sumOutput struct {
+	Sum   float64 `json:"sum"`
+	Count int     `json:"count"`
+}
+
+type sumText float64
+
+func (sum sumText) String() string {
+	return "sum is " + strconv.FormatFloat(float64(sum), 'f', -1, 64)
+}
+
+func sum(_ context.Context, input sumInput) (sumOutput, error) {
+	if len(input.Numbers) == 0 {
+		return sumOutput{}, errors.New("no numbers")
+	}
+
+	output := sumOutput{Count: len(input.Numbers)}
+
+	for _, number := range input.Numbers {
+		output.Sum += number
+	}
+
+	return output, nil
+}
+
+func newSumRequest(arguments any) mcp.CallToolRequest {
+	return mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "sum",
+			Arguments: arguments,
+		},
+	}
+}
+
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+
+	require.Len(t, result.Content, 1)
+
+	text, isText := mcp.AsTextContent(result.Content[0])
+	require.True(t, isText)
+
+	return text.Text
+}
+
+func TestFuncNew(t *testing.T) {
+	tool := Func("sum", "Add numbers", sum)
+	assert.Equal(t, "sum", tool.Name())
+
+	instance, err := tool.New()
+	require.NoError(t, err)
+
+	assert.Equal(t, "Add numbers", instance.Description)
+	assert.Equal(t, []string{"numbers"}, instance.InputSchema.Required)
+	assert.Equal(t, schemaTypeObject, instance.OutputSchema.Type)
+	assert.Contains(t, instance.OutputSchema.Properties, "sum")
+	assert.Contains(t, instance.OutputSchema.Properties, "count")
+}
+
+func TestFuncExec(t *testing.T) {
+	tests := []struct {
+		name           string
+		tool           Tool
+		arguments      any
+		wantText       string
+		wantStructured any
+		wantErr        string
+	}{
+		{
+			name:           "JSON text and structured content",
+			tool:           Func("sum", "Add numbers", sum),
+			arguments:      map[string]any{"numbers": []any{1, 2.5}},
+			wantText:       "{\n  \"sum\": 3.5,\n  \"count\": 2\n}",
+			wantStructured: sumOutput{Sum: 3.5, Count: 2},
+		},
+		{
+			name: "template text",
+			tool: Func("sum", "Add numbers", sum).WithTemplate(
+				template.Must(template.New("sum").Parse("{{ .Count }} numbers sum to {{ .Sum }}")),
+			),
+			arguments:      map[string]any{"numbers": []any{1, 2}},
+			wantText:       "2 numbers sum to 3",
+			wantStructured: sumOutput{Sum: 3, Count: 2},
+		},
+		{
+			name: "stringer text without structured content",
+			tool: Func("sum", "Add numbers", func(ctx context.Context, input sumInput) (sumText, error) {
+				output, err := sum(ctx, input)
+
+				return sumText(output.Sum), err
+			}),
+			arguments: map[string]any{"numbers": []any{1, 2}},
+			wantText:  "sum is 3",
+		},
+		{
+			name: "pointer output",
+			tool: Func("sum", "Add numbers", func(ctx context.Context, input sumInput) (*sumOutput, error) {
+				output, err := sum(ctx, input)
+
+				return &output, err
+			}),
+			arguments:      map[string]any{"numbers": []any{1, 2}},
+			wantText:       "{\n  \"sum\": 3,\n  \"count\": 2\n}",
+			wantStructured: &sumOutput{Sum: 3, Count: 2},
+		},
+		{
+			name: "nil pointer output",
+			tool: Func("sum", "Add numbers", func(context.Context, sumInput) (*sumOutput, error) {
+				return nil, nil //nolint:nilnil
+			}),
+			arguments: map[string]any{"numbers": []any{1}},
+			wantErr:   `tool \"sum\" returned no output`,
+		},
+		{
+			name:      "invalid arguments",
+			tool:      Func("sum", "Add numbers", sum),
+			arguments: map[string]any{},
+			wantErr:   `missing argument \"numbers\"`,
+		},
+		{
+			name:      "function error",
+			tool:      Func("sum", "Add numbers", sum),
+			arguments: map[string]any{"numbers": []any{}},
+			wantErr:   "no numbers",
+		},
+		{
+			name: "template error",
+			tool: Func("sum", "Add numbers", sum).WithTemplate(
+				template.Must(template.New("sum").Parse("{{ .Missing }}")),
+			),
+			arguments: map[string]any{"numbers": []any{1}},
+			wantErr:   "render",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.tool.Exec(t.Context(), newSumRequest(tt.arguments))
+			require.NoError(t, err)
+
+			if len(tt.wantErr) != 0 {
+				assert.True(t, result.IsError)
+				assert.Contains(t, resultText(t, result), tt.wantErr)
+
+				return
+			}
+
+			assert.False(t, result.IsError)
+			assert.Equal(t, tt.wantText, resultText(t, result))
+			assert.Equal(t, tt.wantStructured, result.StructuredContent)
+		})
+	}
+}
+
+func TestFuncWithOptions(t *testing.T) {
+	tool := Func("sum", "Add numbers", sum).WithOptions(
+		WithConfigurationOption(map[string]*int{"one": new(int)}),
+	)
+
+	instance, err := tool.New()
+	require.NoError(t, err)
+
+	assert.Contains(t, instance.InputSchema.Properties, argumentConfiguration)
+}
+
+func TestFuncStructuredContentOverMCP(t *testing.T) {
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{Func("sum", "Add numbers", sum)}))
+
+	cli, err := client.NewInProcessClient(srv)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	initializeClient(t, cli)
+
+	listed, err := cli.ListTools(t.Context(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	require.Len(t, listed.Tools, 1)
+	assert.Equal(t, schemaTypeObject, listed.Tools[0].OutputSchema.Type)
+
+	result, err := cli.CallTool(t.Context(), newSumRequest(map[string]any{"numbers": []any{1, 2}}))
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(result.StructuredContent)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sum": 3, "count": 2}`, string(encoded))
+}
@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/transform-ia/mcp-tools/pkg/telemetry"
+)
+
+const (
+	// HealthEndpoint is the path of the liveness probe
+	HealthEndpoint = "/healthz"
+	// ReadyEndpoint is the path of the readiness probe
+	ReadyEndpoint = "/readyz"
+	// healthCheckTimeout is how long a HealthChecker is waited for
+	healthCheckTimeout = 5 * time.Second
+	healthOK           = "ok"
+	healthFailed       = "failed"
+	healthDraining     = "draining"
+)
+
+// HealthChecker is implemented by the resources a server depend on, like
+// the backend of a configuration, to tell if they are usable
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthCheckerFunc implements HealthChecker with a function
+type HealthCheckerFunc func(ctx context.Context) error
+
+// CheckHealth call f
+func (f HealthCheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// URLHealthChecker check the host of a URL accept TCP connections, on the
+// port of the URL or the default one of its scheme
+type URLHealthChecker struct {
+	URL *url.URL
+}
+
+// CheckHealth dial the host of the URL
+func (checker URLHealthChecker) CheckHealth(ctx context.Context) error {
+	port := checker.URL.Port()
+	if port == "" {
+		scheme := strings.ToLower(checker.URL.Scheme)
+
+		defaultPort, err := net.LookupPort("tcp", scheme)
+		if err != nil {
+			return errors.Wrapf(err, "net.LookupPort(%q)", scheme)
+		}
+
+		port = strconv.Itoa(defaultPort)
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(checker.URL.Hostname(), port))
+	if err != nil {
+		return errors.Wrap(err, "DialContext")
+	}
+
+	return errors.Wrap(conn.Close(), "Close")
+}
+
+// URLHealthCheckers create a URLHealthChecker of each URL, like the ones of
+// GetEnvironmentURLS
+func URLHealthCheckers(urls map[string]*url.URL) map[string]HealthChecker {
+	checkers := make(map[string]HealthChecker, len(urls))
+
+	for name, parsed := range urls {
+		checkers[name] = URLHealthChecker{URL: parsed}
+	}
+
+	return checkers
+}
+
+// HealthCheckers select the resources implementing HealthChecker
+func HealthCheckers[T any](resources map[string]*T) map[string]HealthChecker {
+	checkers := make(map[string]HealthChecker)
+
+	for name, resource := range resources {
+		if checker, isChecker := any(resource).(HealthChecker); isChecker {
+			checkers[name] = checker
+		}
+	}
+
+	return checkers
+}
+
+// HealthHandler answer the liveness probe, a server able to answer being alive
+func HealthHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintln(writer, healthOK)
+	})
+}
+
+// checkHealth run the checkers concurrently, each one within
+// healthCheckTimeout, and return the error of each checker by name
+func checkHealth(ctx context.Context, checkers map[string]HealthChecker) map[string]error {
+	var (
+		results = make(map[string]error, len(checkers))
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+
+	for name, checker := range checkers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			err := checker.CheckHealth(checkCtx)
+
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// ReadyHandler answer the readiness probe, failing with 503 Service
+// Unavailable when a checker fail. The body list the status of each checker,
+// their errors being logged as they may tell the addresses of the backends
+func ReadyHandler(checkers map[string]HealthChecker) http.Handler {
+	return readyHandler(checkers, nil)
+}
+
+// readyHandler is ReadyHandler, failing also once draining is set
+func readyHandler(checkers map[string]HealthChecker, draining *atomic.Bool) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if draining != nil && draining.Load() {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprintln(writer, healthDraining)
+
+			return
+		}
+
+		var (
+			ctx     = request.Context()
+			results = checkHealth(ctx, checkers)
+			names   = slices.Sorted(maps.Keys(results))
+			body    strings.Builder
+			status  = http.StatusOK
+		)
+
+		for _, name := range names {
+			if err := results[name]; err != nil {
+				status = http.StatusServiceUnavailable
+
+				telemetry.LoggerFromContext(ctx).WarnContext(ctx, "health check failed",
+					"checker", name, "error", err.Error())
+				fmt.Fprintf(&body, "%s: %s\n", name, healthFailed)
+
+				continue
+			}
+
+			fmt.Fprintf(&body, "%s: %s\n", name, healthOK)
+		}
+
+		if status == http.StatusOK {
+			body.WriteString(healthOK + "\n")
+		}
+
+		writer.WriteHeader(status)
+		_, _ = writer.Write([]byte(body.String()))
+	})
+}
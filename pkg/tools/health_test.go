@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthyResource is a configuration resource implementing HealthChecker
+type healthyResource struct {
+	err error
+}
+
+func (resource *healthyResource) CheckHealth(context.Context) error {
+	return resource.err
+}
+
+// serveProbe call handler and return its status code and body
+func serveProbe(t *testing.T, handler http.Handler) (int, string) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil))
+
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestHealthHandler(t *testing.T) {
+	status, body := serveProbe(t, HealthHandler())
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok\n", body)
+}
+
+func TestReadyHandler(t *testing.T) {
+	var (
+		healthy = HealthCheckerFunc(func(context.Context) error { return nil })
+		down    = HealthCheckerFunc(func(context.Context) error { return errors.New("connection refused") })
+	)
+
+	tests := []struct {
+		name       string
+		checkers   map[string]HealthChecker
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "no checker",
+			wantStatus: http.StatusOK,
+			wantBody:   "ok\n",
+		},
+		{
+			name:       "healthy",
+			checkers:   map[string]HealthChecker{"b": healthy, "a": healthy},
+			wantStatus: http.StatusOK,
+			wantBody:   "a: ok\nb: ok\nok\n",
+		},
+		{
+			name:       "unreachable backend",
+			checkers:   map[string]HealthChecker{"a": healthy, "b": down},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "a: ok\nb: failed\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := serveProbe(t, ReadyHandler(tt.checkers))
+
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestReadyHandlerTimeout(t *testing.T) {
+	blocking := HealthCheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	recorder := httptest.NewRecorder()
+	ReadyHandler(map[string]HealthChecker{"blocking": blocking}).ServeHTTP(
+		recorder,
+		httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil),
+	)
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "blocking: failed\n", recorder.Body.String())
+}
+
+func TestReadyHandlerDraining(t *testing.T) {
+	var draining atomic.Bool
+
+	handler := readyHandler(nil, &draining)
+
+	status, body := serveProbe(t, handler)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok\n", body)
+
+	draining.Store(true)
+
+	status, body = serveProbe(t, handler)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "draining\n", body)
+}
+
+func TestURLHealthChecker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+
+	defer func() {
+		assert.NoError(t, listener.Close())
+	}()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "reachable", url: "http://" + listener.Addr().String() + "/path"},
+		{name: "unreachable", url: "http://" + closed.Addr().String(), wantErr: true},
+		{name: "unknown scheme without port", url: "unknown://127.0.0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			checkers := URLHealthCheckers(map[string]*url.URL{"backend": parsed})
+			require.Contains(t, checkers, "backend")
+
+			err = checkers["backend"].CheckHealth(t.Context())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHealthCheckers(t *testing.T) {
+	var (
+		healthy = &healthyResource{}
+		down    = &healthyResource{err: errors.New("down")}
+	)
+
+	checkers := HealthCheckers(map[string]*healthyResource{"healthy": healthy, "down": down})
+	assert.Equal(t, map[string]HealthChecker{"healthy": healthy, "down": down}, checkers)
+
+	assert.Empty(t, HealthCheckers(map[string]*url.URL{"url": {}}))
+}
+
+func TestServeHealth(t *testing.T) {
+	baseURL := startHTTPTransport(
+		t,
+		newEchoServer(t),
+		TransportSSE,
+		WithHealthCheckers(map[string]HealthChecker{"backend": &healthyResource{err: errors.New("down")}}),
+	)
+
+	assert.Equal(t, "ok\n", getBody(t, baseURL+HealthEndpoint))
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, baseURL+ReadyEndpoint, nil)
+	require.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+}
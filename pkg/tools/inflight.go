@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+)
+
+type inFlightKey struct{}
+
+// inFlight count the Tool.Exec calls in progress of a served MCP server
+type inFlight struct {
+	mutex sync.Mutex
+	count int
+	// idle is closed when count drops to zero
+	idle chan struct{}
+}
+
+func newInFlight() *inFlight {
+	idle := make(chan struct{})
+	close(idle)
+
+	return &inFlight{idle: idle}
+}
+
+func (calls *inFlight) add() {
+	calls.mutex.Lock()
+	defer calls.mutex.Unlock()
+
+	if calls.count == 0 {
+		calls.idle = make(chan struct{})
+	}
+
+	calls.count++
+}
+
+func (calls *inFlight) done() {
+	calls.mutex.Lock()
+	defer calls.mutex.Unlock()
+
+	calls.count--
+	if calls.count == 0 {
+		close(calls.idle)
+	}
+}
+
+// wait until there is no call in progress or ctx is done
+func (calls *inFlight) wait(ctx context.Context) error {
+	calls.mutex.Lock()
+	idle := calls.idle
+	calls.mutex.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "waiting for in-flight tool calls")
+	}
+}
+
+// withInFlight attach calls to ctx so tool calls served under it are counted
+func withInFlight(ctx context.Context, calls *inFlight) context.Context {
+	return context.WithValue(ctx, inFlightKey{}, calls)
+}
+
+// trackInFlight count the calls of handler in the inFlight of their context
+func trackInFlight(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if calls, isInFlight := ctx.Value(inFlightKey{}).(*inFlight); isInFlight {
+			calls.add()
+			defer calls.done()
+		}
+
+		return handler(ctx, request)
+	}
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInFlightWait(t *testing.T) {
+	calls := newInFlight()
+	require.NoError(t, calls.wait(t.Context()), "no call in progress")
+
+	calls.add()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, calls.wait(ctx), context.DeadlineExceeded)
+
+	calls.done()
+	require.NoError(t, calls.wait(t.Context()))
+}
+
+func TestTrackInFlight(t *testing.T) {
+	var (
+		calls   = newInFlight()
+		release = make(chan struct{})
+		started = make(chan struct{})
+		handler = trackInFlight(func(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			close(started)
+			<-release
+
+			return mcp.NewToolResultText(released), nil
+		})
+	)
+
+	go func() {
+		_, err := handler(withInFlight(t.Context(), calls), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	require.Error(t, calls.wait(ctx), "call must be in-flight")
+
+	close(release)
+	require.NoError(t, calls.wait(t.Context()))
+
+	result, err := trackInFlight(newEchoTool().Exec)(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err, "untracked context must be served")
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+)
+
+// Middleware wrap a Tool to add a cross-cutting behavior to it
+type Middleware func(Tool) Tool
+
+// ExecFunc is the signature of Tool.Exec
+type ExecFunc func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+// execTool is a Tool whose Exec is replaced
+type execTool struct {
+	Tool
+
+	exec ExecFunc
+}
+
+func (tool *execTool) Exec(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return tool.exec(ctx, request)
+}
+
+// WrapExec return tool with its Exec replaced by exec, to write a Middleware
+// that only change how a tool is called
+func WrapExec(tool Tool, exec ExecFunc) Tool {
+	return &execTool{
+		Tool: tool,
+		exec: exec,
+	}
+}
+
+// Chain wrap tool with middlewares, the first one being the outermost
+func Chain(tool Tool, middlewares ...Middleware) Tool {
+	for index := len(middlewares) - 1; index >= 0; index-- {
+		tool = middlewares[index](tool)
+	}
+
+	return tool
+}
+
+// RecoveryMiddleware turn a panic of Tool.Exec into an error result
+func RecoveryMiddleware() Middleware {
+	return func(tool Tool) Tool {
+		//nolint:nonamedreturns
+		return WrapExec(tool, func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					result = TextContentError(errors.Errorf("tool %q panicked: %v", tool.Name(), recovered))
+					err = nil
+				}
+			}()
+
+			return tool.Exec(ctx, request)
+		})
+	}
+}
+
+// timeoutGracePeriod is how long TimeoutMiddleware wait for a call to return
+// once its context is done
+const timeoutGracePeriod = 100 * time.Millisecond
+
+// TimeoutMiddleware cancel the context of Tool.Exec after a timeout, the one
+// of timeouts keyed by tool name or else defaultTimeout. Zero means no timeout.
+// A call that does not return within a grace period once its context is done
+// get an error result, the tool being left running in the background until
+// it returns: tools must honor the cancellation of their context. A panic of
+// the call is turned into an error result like with RecoveryMiddleware.
+func TimeoutMiddleware(defaultTimeout time.Duration, timeouts map[string]time.Duration) Middleware {
+	return func(tool Tool) Tool {
+		timeout, exists := timeouts[tool.Name()]
+		if !exists {
+			timeout = defaultTimeout
+		}
+
+		if timeout <= 0 {
+			return tool
+		}
+
+		return WrapExec(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			type execResult struct {
+				result *mcp.CallToolResult
+				err    error
+			}
+
+			done := make(chan execResult, 1)
+
+			go func() {
+				// a panic of this goroutine is out of the reach of RecoveryMiddleware
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						done <- execResult{result: TextContentError(errors.Errorf("tool %q panicked: %v", tool.Name(), recovered))}
+					}
+				}()
+
+				result, err := tool.Exec(ctx, request)
+				done <- execResult{result: result, err: err}
+			}()
+
+			select {
+			case output := <-done:
+				return output.result, output.err
+			case <-ctx.Done():
+				// prefer the outcome of a tool honoring its context
+				grace := time.NewTimer(timeoutGracePeriod)
+				defer grace.Stop()
+
+				select {
+				case output := <-done:
+					return output.result, output.err
+				case <-grace.C:
+				}
+
+				return TextContentError(errors.Wrapf(ctx.Err(), "tool %q timed out after %s", tool.Name(), timeout)), nil
+			}
+		})
+	}
+}
+
+// LoggingMiddleware log each call of Tool.Exec and its outcome with logger,
+// the resolved secrets being redacted (see secret.Redact). Only the names of
+// the arguments are logged, their values may be sensitive
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	const (
+		keyTool      = "tool"
+		keyArguments = "arguments"
+		keyDuration  = "duration"
+		keyIsError   = "is_error"
+	)
+
+	logger = slog.New(secret.NewRedactingHandler(logger.Handler()))
+
+	return func(tool Tool) Tool {
+		return WrapExec(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			logger.DebugContext(
+				ctx,
+				"tool call",
+				slog.String(keyTool, tool.Name()),
+				slog.Any(keyArguments, slices.Sorted(maps.Keys(request.GetArguments()))),
+			)
+
+			start := time.Now()
+			result, err := tool.Exec(ctx, request)
+			duration := time.Since(start)
+
+			switch {
+			case err != nil:
+				logger.ErrorContext(
+					ctx,
+					"tool call failed",
+					slog.String(keyTool, tool.Name()),
+					slog.Duration(keyDuration, duration),
+					slog.String("error", err.Error()),
+				)
+			case result != nil && result.IsError:
+				logger.WarnContext(
+					ctx,
+					"tool call returned an error",
+					slog.String(keyTool, tool.Name()),
+					slog.Duration(keyDuration, duration),
+					slog.Bool(keyIsError, true),
+				)
+			default:
+				logger.InfoContext(
+					ctx,
+					"tool call succeeded",
+					slog.String(keyTool, tool.Name()),
+					slog.Duration(keyDuration, duration),
+					slog.Bool(keyIsError, false),
+				)
+			}
+
+			return result, err
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeToolName = "fake"
+
+// fakeTool is a Tool whose definition and Exec are given by the test
+type fakeTool struct {
+	// name of the tool, fakeToolName when empty
+	name string
+	// options of the definition, created by each New when not nil
+	options func() ([]mcp.ToolOption, error)
+	exec    ExecFunc
+}
+
+// withOptions return the fakeTool options creating options
+func withOptions(options ...mcp.ToolOption) func() ([]mcp.ToolOption, error) {
+	return func() ([]mcp.ToolOption, error) {
+		return options, nil
+	}
+}
+
+func (tool *fakeTool) Name() string {
+	if tool.name == "" {
+		return fakeToolName
+	}
+
+	return tool.name
+}
+
+func (tool *fakeTool) New() (*mcp.Tool, error) {
+	var options []mcp.ToolOption
+
+	if tool.options != nil {
+		var err error
+
+		if options, err = tool.options(); err != nil {
+			return nil, err
+		}
+	}
+
+	instance := mcp.NewTool(tool.Name(), options...)
+
+	return &instance, nil
+}
+
+func (tool *fakeTool) Exec(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return tool.exec(ctx, request)
+}
+
+func newFakeTool(result *mcp.CallToolResult, err error) *fakeTool {
+	return &fakeTool{
+		exec: func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return result, err
+		},
+	}
+}
+
+// recordMiddleware append name to calls before and after each call
+func recordMiddleware(name string, calls *[]string) Middleware {
+	return func(tool Tool) Tool {
+		return WrapExec(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			*calls = append(*calls, "before "+name)
+			result, err := tool.Exec(ctx, request)
+			*calls = append(*calls, "after "+name)
+
+			return result, err
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+
+	tool := Chain(
+		newFakeTool(mcp.NewToolResultText("ok"), nil),
+		recordMiddleware("outer", &calls),
+		recordMiddleware("inner", &calls),
+	)
+
+	assert.Equal(t, fakeToolName, tool.Name())
+
+	result, err := tool.Exec(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resultText(t, result))
+	assert.Equal(t, []string{"before outer", "before inner", "after inner", "after outer"}, calls)
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	tool := Chain(&fakeTool{
+		exec: func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			panic("boom")
+		},
+	}, RecoveryMiddleware())
+
+	result, err := tool.Exec(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "boom")
+
+	result, err = Chain(newFakeTool(mcp.NewToolResultText("ok"), nil), RecoveryMiddleware()).
+		Exec(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resultText(t, result))
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		slow    = &fakeTool{
+			exec: func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				select {
+				case <-release:
+				case <-time.After(time.Second):
+				}
+
+				return mcp.NewToolResultText("late"), nil
+			},
+		}
+		cancelled = &fakeTool{
+			exec: func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				<-ctx.Done()
+
+				return nil, errors.Wrap(ctx.Err(), "cancelled")
+			},
+		}
+		panicking = &fakeTool{
+			exec: func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				panic("boom")
+			},
+		}
+	)
+	defer close(release)
+
+	tests := []struct {
+		name           string
+		tool           Tool
+		defaultTimeout time.Duration
+		timeouts       map[string]time.Duration
+		wantDeadline   bool
+		wantText       string
+	}{
+		{
+			name:           "ignoring context",
+			tool:           slow,
+			defaultTimeout: 10 * time.Millisecond,
+			wantText:       "timed out",
+		},
+		{
+			name:           "panicking",
+			tool:           panicking,
+			defaultTimeout: time.Second,
+			wantText:       "panicked: boom",
+		},
+		{
+			name:           "honoring context",
+			tool:           cancelled,
+			defaultTimeout: 10 * time.Millisecond,
+			wantDeadline:   true,
+		},
+		{
+			name:           "per tool timeout",
+			tool:           cancelled,
+			defaultTimeout: time.Hour,
+			timeouts:       map[string]time.Duration{fakeToolName: 10 * time.Millisecond},
+			wantDeadline:   true,
+		},
+		{
+			name:     "no timeout",
+			tool:     newFakeTool(mcp.NewToolResultText("ok"), nil),
+			timeouts: map[string]time.Duration{"other": time.Nanosecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Chain(tt.tool, TimeoutMiddleware(tt.defaultTimeout, tt.timeouts)).
+				Exec(t.Context(), mcp.CallToolRequest{})
+			if tt.wantDeadline {
+				// the outcome of the tool is kept within the grace period
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.ErrorContains(t, err, "cancelled")
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText != "", result.IsError)
+
+			if tt.wantText != "" {
+				assert.Contains(t, resultText(t, result), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     Tool
+		wantLogs []string
+	}{
+		{
+			name: "success",
+			tool: newFakeTool(mcp.NewToolResultText("ok"), nil),
+			wantLogs: []string{
+				`level=DEBUG msg="tool call" tool=fake arguments="[key other]"`,
+				`level=INFO msg="tool call succeeded" tool=fake`,
+			},
+		},
+		{
+			name:     "error result",
+			tool:     newFakeTool(TextContentError(errors.New("invalid")), nil),
+			wantLogs: []string{`level=WARN msg="tool call returned an error" tool=fake`},
+		},
+		{
+			name:     "error",
+			tool:     newFakeTool(nil, errors.New("failure")),
+			wantLogs: []string{`level=ERROR msg="tool call failed" tool=fake`, `error=failure`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				buf    = bytes.NewBuffer(nil)
+				logger = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			)
+
+			_, _ = Chain(tt.tool, LoggingMiddleware(logger)).Exec(t.Context(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Arguments: map[string]any{"other": 1, "key": "sensitive"}},
+			})
+
+			for _, wantLog := range tt.wantLogs {
+				assert.Contains(t, buf.String(), wantLog)
+			}
+
+			assert.NotContains(t, buf.String(), "sensitive")
+		})
+	}
+}
+
+func TestServerAddToolsMiddlewares(t *testing.T) {
+	var calls []string
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newEchoTool()}, recordMiddleware("middleware", &calls)))
+
+	cli, err := client.NewInProcessClient(srv)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	assertEchoCall(t, cli)
+	assert.Equal(t, []string{"before middleware", "after middleware"}, calls)
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v3"
+
+	"github.com/transform-ia/mcp-tools/pkg/telemetry"
+)
+
+const (
+	// envAuthzPolicyFile is the policy file the tool calls are authorized with
+	envAuthzPolicyFile = "MCP_AUTHZ_POLICY_FILE"
+	// policyWildcard allow every tool or configuration
+	policyWildcard = "*"
+)
+
+// Names of the telemetry of denied tool calls
+const (
+	// EventToolCallDenied is the span event of a call denied by a Policy
+	EventToolCallDenied = "mcp.tool.call_denied"
+	// AttributePrincipalName is the name of the caller
+	AttributePrincipalName = attribute.Key("mcp.principal.name")
+	// AttributePrincipalMethod is the authentication method of the caller
+	AttributePrincipalMethod = attribute.Key("mcp.principal.method")
+)
+
+// ErrPermissionDenied is returned by Policy.Authorize when a call is not
+// allowed
+var ErrPermissionDenied = errors.New("permission denied")
+
+// PolicyRule is what a principal is allowed to call
+type PolicyRule struct {
+	// Tools are the names of the tools the principal may call, "*" allowing
+	// every tool
+	Tools []string `json:"tools" yaml:"tools"`
+	// Configurations are the values of the configuration argument (see
+	// SelectFromConfiguration) the principal may select, "*" allowing every
+	// configuration. Tools without configuration argument are not restricted
+	Configurations []string `json:"configurations" yaml:"configurations"`
+}
+
+// allowTool tell if the rule allow the tool named name
+func (rule *PolicyRule) allowTool(name string) bool {
+	return slices.Contains(rule.Tools, policyWildcard) || slices.Contains(rule.Tools, name)
+}
+
+// allowConfiguration tell if the rule allow the configuration
+func (rule *PolicyRule) allowConfiguration(configuration string) bool {
+	return slices.Contains(rule.Configurations, policyWildcard) || slices.Contains(rule.Configurations, configuration)
+}
+
+// Policy authorize the tool calls of the principals, like:
+//
+//	principals:
+//	  alice:
+//	    tools: ["*"]
+//	    configurations: ["staging", "production"]
+//	  jwt:ci-bot:
+//	    tools: ["search"]
+//	    configurations: ["staging"]
+//	default:
+//	  tools: ["version"]
+type Policy struct {
+	// Principals map the name of a principal to its rule. The name can be
+	// qualified by the authentication method, like "jwt:alice", the qualified
+	// rule being preferred to the unqualified one
+	Principals map[string]*PolicyRule `json:"principals" yaml:"principals"`
+	// Default is the rule of the principals not listed, including the
+	// unauthenticated callers. Without it, they are not allowed anything
+	Default *PolicyRule `json:"default,omitempty" yaml:"default"`
+}
+
+// LoadPolicy read a Policy from a YAML or JSON file, failing on unknown fields
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+
+	var (
+		policy  = &Policy{}
+		decoder = yaml.NewDecoder(bytes.NewReader(content))
+	)
+
+	decoder.KnownFields(true)
+
+	if err = decoder.Decode(policy); err != nil {
+		return nil, errors.Wrapf(err, "yaml.Decode(%q)", path)
+	}
+
+	return policy, nil
+}
+
+// PolicyFromEnv load the Policy of the file MCP_AUTHZ_POLICY_FILE, nil when
+// it is not defined, to be given to AuthorizationMiddleware
+func PolicyFromEnv() (*Policy, error) {
+	path := os.Getenv(envAuthzPolicyFile)
+	if path == "" {
+		//nolint:nilnil
+		return nil, nil
+	}
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "LoadPolicy")
+	}
+
+	return policy, nil
+}
+
+// rule return the rule of principal, nil when it is not allowed anything
+func (policy *Policy) rule(principal *Principal) *PolicyRule {
+	if principal != nil {
+		if rule, exists := policy.Principals[principal.Method+":"+principal.Name]; exists {
+			return rule
+		}
+
+		if rule, exists := policy.Principals[principal.Name]; exists {
+			return rule
+		}
+	}
+
+	return policy.Default
+}
+
+// Authorize tell if principal, nil when the caller is not authenticated, may
+// call the tool named toolName with configuration, empty when the call does
+// not select one. The error wrap ErrPermissionDenied
+func (policy *Policy) Authorize(principal *Principal, toolName, configuration string) error {
+	rule := policy.rule(principal)
+
+	if rule == nil || !rule.allowTool(toolName) {
+		return errors.Wrapf(ErrPermissionDenied, "tool %q", toolName)
+	}
+
+	if configuration != "" && !rule.allowConfiguration(configuration) {
+		return errors.Wrapf(ErrPermissionDenied, "tool %q with configuration %q", toolName, configuration)
+	}
+
+	return nil
+}
+
+// AuthorizationMiddleware authorize each call of Tool.Exec with policy and
+// the principal of its context (see PrincipalFromContext). A denied call is
+// not executed, it returns an error result and is recorded as an
+// EventToolCallDenied event of the span of the context, which is the one of
+// the tool call when TelemetryMiddleware is an outer middleware. A nil
+// policy authorize every call.
+func AuthorizationMiddleware(policy *Policy) Middleware {
+	return func(tool Tool) Tool {
+		if policy == nil {
+			return tool
+		}
+
+		return WrapExec(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var (
+				principal      = PrincipalFromContext(ctx)
+				configurations = requestConfigurations(&request)
+				configuration  string
+				err            error
+			)
+
+			if len(configurations) == 0 {
+				configurations = []string{""}
+			}
+
+			// each configuration of a fan-out call is authorized
+			for _, configuration = range configurations {
+				if err = policy.Authorize(principal, tool.Name(), configuration); err != nil {
+					break
+				}
+			}
+
+			if err == nil {
+				return tool.Exec(ctx, request)
+			}
+
+			attributes := []attribute.KeyValue{AttributeToolName.String(tool.Name())}
+
+			if configuration != "" {
+				attributes = append(attributes, AttributeToolConfiguration.String(configuration))
+			}
+
+			if principal != nil {
+				attributes = append(
+					attributes,
+					AttributePrincipalName.String(principal.Name),
+					AttributePrincipalMethod.String(principal.Method),
+				)
+			}
+
+			trace.SpanFromContext(ctx).AddEvent(EventToolCallDenied, trace.WithAttributes(attributes...))
+
+			telemetry.LoggerFromContext(ctx).WarnContext(ctx, "tool call denied", "error", err.Error())
+
+			return TextContentError(err), nil
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const testPolicy = `
+principals:
+  alice:
+    tools: ["*"]
+    configurations: ["staging", "production"]
+  jwt:alice:
+    tools: ["fake"]
+    configurations: ["*"]
+  bob:
+    tools: ["fake"]
+    configurations: ["staging"]
+default:
+  tools: ["version"]
+`
+
+// writePolicy write content to a policy file and return its path
+func writePolicy(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    *Policy
+		wantErr bool
+	}{
+		{
+			name:    "yaml",
+			file:    "policy.yaml",
+			content: "principals:\n  bob:\n    tools: [fake]\n",
+			want:    &Policy{Principals: map[string]*PolicyRule{"bob": {Tools: []string{fakeToolName}}}},
+		},
+		{
+			name:    "json",
+			file:    "policy.json",
+			content: `{"principals": {}, "default": {"tools": ["*"], "configurations": ["staging"]}}`,
+			want: &Policy{
+				Principals: map[string]*PolicyRule{},
+				Default:    &PolicyRule{Tools: []string{"*"}, Configurations: []string{"staging"}},
+			},
+		},
+		{name: "unknown field", file: "policy.yaml", content: "principal:\n  bob: {}\n", wantErr: true},
+		{name: "invalid", file: "policy.yaml", content: "principals: [", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadPolicy(writePolicy(t, tt.file, tt.content))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv(envAuthzPolicyFile, "")
+
+	policy, err := PolicyFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+
+	t.Setenv(envAuthzPolicyFile, writePolicy(t, "policy.yaml", testPolicy))
+
+	policy, err = PolicyFromEnv()
+	require.NoError(t, err)
+	assert.Len(t, policy.Principals, 3)
+
+	t.Setenv(envAuthzPolicyFile, filepath.Join(t.TempDir(), "missing.yaml"))
+
+	_, err = PolicyFromEnv()
+	require.Error(t, err)
+}
+
+func TestPolicyAuthorize(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, "policy.yaml", testPolicy))
+	require.NoError(t, err)
+
+	var (
+		alice    = &Principal{Name: "alice", Method: AuthMethodBearer}
+		aliceJWT = &Principal{Name: "alice", Method: AuthMethodJWT}
+		bob      = &Principal{Name: "bob", Method: AuthMethodMTLS}
+		carol    = &Principal{Name: "carol", Method: AuthMethodBearer}
+	)
+
+	tests := []struct {
+		name          string
+		principal     *Principal
+		tool          string
+		configuration string
+		wantErr       bool
+	}{
+		{name: "any tool", principal: alice, tool: "search", configuration: "production"},
+		{name: "configuration not allowed", principal: alice, tool: "search", configuration: "dev", wantErr: true},
+		{name: "without configuration", principal: alice, tool: "search"},
+		{name: "qualified rule", principal: aliceJWT, tool: fakeToolName, configuration: "dev"},
+		{name: "qualified rule tool not allowed", principal: aliceJWT, tool: "search", wantErr: true},
+		{name: "allowed tool", principal: bob, tool: fakeToolName, configuration: "staging"},
+		{name: "tool not allowed", principal: bob, tool: "search", configuration: "staging", wantErr: true},
+		{name: "default rule", principal: carol, tool: "version"},
+		{name: "default rule tool not allowed", principal: carol, tool: fakeToolName, wantErr: true},
+		{name: "unauthenticated", tool: "version"},
+		{name: "unauthenticated tool not allowed", tool: fakeToolName, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(tt.principal, tt.tool, tt.configuration)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrPermissionDenied)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+
+	err = (&Policy{}).Authorize(alice, fakeToolName, "")
+	require.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	policy, err := LoadPolicy(writePolicy(t, "policy.yaml", testPolicy))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		principal      *Principal
+		configuration  any
+		wantExec       bool
+		wantAttributes []attribute.KeyValue
+	}{
+		{
+			name:          "allowed",
+			principal:     &Principal{Name: "bob", Method: AuthMethodMTLS},
+			configuration: "staging",
+			wantExec:      true,
+		},
+		{
+			name:          "denied configuration",
+			principal:     &Principal{Name: "bob", Method: AuthMethodMTLS},
+			configuration: "production",
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolConfiguration.String("production"),
+				AttributePrincipalName.String("bob"),
+				AttributePrincipalMethod.String(AuthMethodMTLS),
+			},
+		},
+		{
+			name:          "allowed configurations",
+			principal:     &Principal{Name: "alice", Method: AuthMethodBearer},
+			configuration: []any{"staging", "production"},
+			wantExec:      true,
+		},
+		{
+			name:          "denied configurations",
+			principal:     &Principal{Name: "bob", Method: AuthMethodMTLS},
+			configuration: []any{"staging", "production"},
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolConfiguration.String("production"),
+				AttributePrincipalName.String("bob"),
+				AttributePrincipalMethod.String(AuthMethodMTLS),
+			},
+		},
+		{
+			name:          "denied all configurations",
+			principal:     &Principal{Name: "bob", Method: AuthMethodMTLS},
+			configuration: AllConfigurations,
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolConfiguration.String(AllConfigurations),
+				AttributePrincipalName.String("bob"),
+				AttributePrincipalMethod.String(AuthMethodMTLS),
+			},
+		},
+		{
+			name: "denied unauthenticated",
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				recorder       = tracetest.NewSpanRecorder()
+				tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+				executed       bool
+				tool           = &fakeTool{
+					exec: func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+						executed = true
+
+						return mcp.NewToolResultText("ok"), nil
+					},
+				}
+			)
+
+			telemetryMiddleware, err := TelemetryMiddleware(tracerProvider, sdkmetric.NewMeterProvider())
+			require.NoError(t, err)
+
+			request := mcp.CallToolRequest{}
+			if tt.configuration != nil {
+				request.Params.Arguments = map[string]any{argumentConfiguration: tt.configuration}
+			}
+
+			result, err := Chain(tool, telemetryMiddleware, AuthorizationMiddleware(policy)).
+				Exec(ContextWithPrincipal(t.Context(), tt.principal), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExec, executed)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+
+			if tt.wantExec {
+				assert.Equal(t, "ok", resultText(t, result))
+				assert.Empty(t, spans[0].Events())
+
+				return
+			}
+
+			assert.True(t, result.IsError)
+			assert.Contains(t, resultText(t, result), ErrPermissionDenied.Error())
+
+			require.Len(t, spans[0].Events(), 1)
+			assert.Equal(t, EventToolCallDenied, spans[0].Events()[0].Name)
+			assert.ElementsMatch(t, tt.wantAttributes, spans[0].Events()[0].Attributes)
+		})
+	}
+}
+
+func TestAuthorizationMiddlewareWithoutPolicy(t *testing.T) {
+	tool := newFakeTool(mcp.NewToolResultText("ok"), nil)
+
+	assert.Same(t, tool, AuthorizationMiddleware(nil)(tool))
+
+	result, err := Chain(tool, AuthorizationMiddleware(nil)).Exec(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resultText(t, result))
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// MetaCarrier adapt the _meta of a MCP request to a propagation.TextMapCarrier
+// so traceparent, tracestate and baggage travel with the request
+type MetaCarrier struct {
+	meta *mcp.Meta
+}
+
+var _ propagation.TextMapCarrier = MetaCarrier{}
+
+// NewMetaCarrier create a MetaCarrier reading and writing meta
+func NewMetaCarrier(meta *mcp.Meta) MetaCarrier {
+	return MetaCarrier{meta: meta}
+}
+
+// Get implements propagation.TextMapCarrier
+func (carrier MetaCarrier) Get(key string) string {
+	if carrier.meta == nil {
+		return ""
+	}
+
+	value, _ := carrier.meta.AdditionalFields[key].(string)
+
+	return value
+}
+
+// Set implements propagation.TextMapCarrier
+func (carrier MetaCarrier) Set(key, value string) {
+	if carrier.meta == nil {
+		return
+	}
+
+	if carrier.meta.AdditionalFields == nil {
+		carrier.meta.AdditionalFields = make(map[string]any)
+	}
+
+	carrier.meta.AdditionalFields[key] = value
+}
+
+// Keys implements propagation.TextMapCarrier
+func (carrier MetaCarrier) Keys() []string {
+	if carrier.meta == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(carrier.meta.AdditionalFields))
+
+	for key, value := range carrier.meta.AdditionalFields {
+		if _, isString := value.(string); isString {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// InjectTraceContext write the trace context and baggage of ctx into the
+// _meta of request with the global propagator, to be called by clients
+func InjectTraceContext(ctx context.Context, request *mcp.CallToolRequest) {
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, NewMetaCarrier(request.Params.Meta))
+}
+
+// ExtractTraceContext return ctx with the trace context and baggage read from
+// the _meta of request with the global propagator
+func ExtractTraceContext(ctx context.Context, request mcp.CallToolRequest) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, NewMetaCarrier(request.Params.Meta))
+}
+
+// extractTraceContext run handler within the trace context of the request
+func extractTraceContext(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handler(ExtractTraceContext(ctx, request), request)
+	}
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setTestPropagator(t *testing.T) {
+	t.Helper()
+
+	previous := otel.GetTextMapPropagator()
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(previous)
+	})
+}
+
+func TestMetaCarrier(t *testing.T) {
+	meta := &mcp.Meta{
+		ProgressToken:    "token",
+		AdditionalFields: map[string]any{"number": 1},
+	}
+
+	carrier := NewMetaCarrier(meta)
+	carrier.Set("traceparent", "value")
+
+	assert.Equal(t, "value", carrier.Get("traceparent"))
+	assert.Empty(t, carrier.Get("number"))
+	assert.Empty(t, carrier.Get("missing"))
+	assert.Equal(t, []string{"traceparent"}, carrier.Keys())
+	assert.Equal(t, "token", meta.ProgressToken)
+
+	empty := NewMetaCarrier(nil)
+	empty.Set("traceparent", "value")
+
+	assert.Empty(t, empty.Get("traceparent"))
+	assert.Empty(t, empty.Keys())
+}
+
+func TestInjectExtractTraceContext(t *testing.T) {
+	setTestPropagator(t)
+
+	tracerProvider := sdktrace.NewTracerProvider()
+
+	ctx, span := tracerProvider.Tracer("test").Start(t.Context(), "client")
+	defer span.End()
+
+	member, err := baggage.NewMember("tenant", "acme")
+	require.NoError(t, err)
+
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+
+	var request mcp.CallToolRequest
+
+	InjectTraceContext(ctx, &request)
+	require.NotNil(t, request.Params.Meta)
+	assert.Contains(t, request.Params.Meta.AdditionalFields, "traceparent")
+	assert.Contains(t, request.Params.Meta.AdditionalFields, "baggage")
+
+	extracted := ExtractTraceContext(context.Background(), request)
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(extracted).TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), trace.SpanContextFromContext(extracted).SpanID())
+	assert.Equal(t, "acme", baggage.FromContext(extracted).Member("tenant").Value())
+}
+
+func TestServerAddToolsTraceContext(t *testing.T) {
+	setTestPropagator(t)
+
+	var (
+		recorder       = tracetest.NewSpanRecorder()
+		tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	)
+
+	middleware, err := TelemetryMiddleware(tracerProvider, sdkmetric.NewMeterProvider())
+	require.NoError(t, err)
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newFakeTool(mcp.NewToolResultText("ok"), nil)}, middleware))
+
+	cli, err := client.NewInProcessClient(srv)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	initializeClient(t, cli)
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(t.Context(), "client")
+	defer span.End()
+
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Name: fakeToolName}}
+	InjectTraceContext(ctx, &request)
+
+	_, err = cli.CallTool(ctx, request)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, span.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.True(t, spans[0].Parent().IsRemote())
+}
@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+)
+
+// Scopes of the rate limits
+const (
+	// RateLimitScopeTool limit the calls of a tool
+	RateLimitScopeTool = "tool"
+	// RateLimitScopeSession limit the calls of an MCP session
+	RateLimitScopeSession = "session"
+	// RateLimitScopeConfiguration limit the calls selecting a configuration
+	RateLimitScopeConfiguration = "configuration"
+)
+
+const (
+	// concurrencyRetryAfter is the delay advised to the calls rejected by a
+	// concurrency cap, which can not tell when a call will end
+	concurrencyRetryAfter = time.Second
+	// limiterPruneInterval is how often the idle limiters are forgotten
+	limiterPruneInterval = time.Minute
+)
+
+// Limit cap the calls of a scope with a token bucket and a semaphore
+type Limit struct {
+	// Rate is the number of calls per second the bucket is refilled with,
+	// zero for no rate limit
+	Rate float64 `json:"rate" yaml:"rate"`
+	// Burst is the capacity of the bucket, the number of calls allowed at
+	// once after an idle period. It is at least one
+	Burst int `json:"burst" yaml:"burst"`
+	// MaxConcurrency is the number of calls allowed in progress at once,
+	// zero for no cap
+	MaxConcurrency int `json:"max_concurrency" yaml:"max_concurrency"`
+}
+
+// RateLimits are the limits of the tool calls by scope, a call being
+// rejected when it exceed any of the limits it is subject to
+type RateLimits struct {
+	// Tools are the limits of the calls of each tool, by tool name
+	Tools map[string]Limit `json:"tools" yaml:"tools"`
+	// Session is the limit of the calls of each MCP session, to any tool
+	Session *Limit `json:"session,omitempty" yaml:"session"`
+	// Configurations are the limits of the calls selecting each
+	// configuration (see SelectFromConfiguration), by configuration
+	Configurations map[string]Limit `json:"configurations" yaml:"configurations"`
+}
+
+// RateLimited is the structured content of the result of a rejected call
+type RateLimited struct {
+	// Error is the message of the error
+	Error string `json:"error"`
+	// Scope of the limit exceeded, like RateLimitScopeSession
+	Scope string `json:"scope"`
+	// Key of the limit in its scope: the tool name, session ID or configuration
+	Key string `json:"key"`
+	// RetryAfterSeconds is how long to wait before calling again
+	RetryAfterSeconds float64 `json:"retry_after_seconds"`
+}
+
+// limiterKey identify a limiter
+type limiterKey struct {
+	scope string
+	key   string
+}
+
+// limiter is the state of a Limit
+type limiter struct {
+	limit    Limit
+	tokens   float64
+	updated  time.Time
+	inFlight int
+}
+
+func newLimiter(limit Limit, now time.Time) *limiter {
+	state := &limiter{limit: limit, updated: now}
+	state.tokens = state.burst()
+
+	return state
+}
+
+// burst is the capacity of the bucket
+func (state *limiter) burst() float64 {
+	return float64(max(1, state.limit.Burst))
+}
+
+// refill the bucket with the tokens earned since the last update
+func (state *limiter) refill(now time.Time) {
+	if state.limit.Rate > 0 && now.After(state.updated) {
+		state.tokens = min(state.burst(), state.tokens+now.Sub(state.updated).Seconds()*state.limit.Rate)
+	}
+
+	state.updated = now
+}
+
+// retryAfter return how long to wait before a call is allowed, zero when it is
+func (state *limiter) retryAfter() time.Duration {
+	if state.limit.MaxConcurrency > 0 && state.inFlight >= state.limit.MaxConcurrency {
+		return concurrencyRetryAfter
+	}
+
+	if state.limit.Rate > 0 && state.tokens < 1 {
+		// rounded up so that a call retried on time is allowed
+		milliseconds := math.Ceil((1 - state.tokens) / state.limit.Rate * 1000)
+
+		return time.Duration(milliseconds) * time.Millisecond
+	}
+
+	return 0
+}
+
+// idle tell if the limiter is in the same state as a new one
+func (state *limiter) idle() bool {
+	return state.inFlight == 0 && (state.limit.Rate <= 0 || state.tokens >= state.burst())
+}
+
+// rateLimiter enforce RateLimits, the limiters being created on first use
+// and forgotten once idle
+type rateLimiter struct {
+	limits   RateLimits
+	now      func() time.Time
+	mu       sync.Mutex
+	limiters map[limiterKey]*limiter
+	pruned   time.Time
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		limits:   limits,
+		now:      time.Now,
+		limiters: make(map[limiterKey]*limiter),
+	}
+}
+
+// limitsOf return the limits a call is subject to, by key
+func (rl *rateLimiter) limitsOf(toolName, sessionID, configuration string) map[limiterKey]Limit {
+	limits := make(map[limiterKey]Limit, 3)
+
+	if limit, exists := rl.limits.Tools[toolName]; exists {
+		limits[limiterKey{scope: RateLimitScopeTool, key: toolName}] = limit
+	}
+
+	if rl.limits.Session != nil && sessionID != "" {
+		limits[limiterKey{scope: RateLimitScopeSession, key: sessionID}] = *rl.limits.Session
+	}
+
+	if limit, exists := rl.limits.Configurations[configuration]; exists && configuration != "" {
+		limits[limiterKey{scope: RateLimitScopeConfiguration, key: configuration}] = limit
+	}
+
+	return limits
+}
+
+// acquire a call of the limits, all or none of them. It return the function
+// ending the call, or the limit exceeded with the longest delay
+func (rl *rateLimiter) acquire(limits map[limiterKey]Limit) (func(), *RateLimited) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	var (
+		now      = rl.now()
+		states   = make(map[limiterKey]*limiter, len(limits))
+		rejected *RateLimited
+	)
+
+	rl.prune(now)
+
+	for key, limit := range limits {
+		state, exists := rl.limiters[key]
+		if !exists {
+			state = newLimiter(limit, now)
+			rl.limiters[key] = state
+		}
+
+		state.refill(now)
+		states[key] = state
+
+		if retryAfter := state.retryAfter(); retryAfter > 0 &&
+			(rejected == nil || retryAfter.Seconds() > rejected.RetryAfterSeconds) {
+			rejected = &RateLimited{
+				Error:             fmt.Sprintf("rate limited by %s %s, retry after %s", key.scope, key.key, retryAfter),
+				Scope:             key.scope,
+				Key:               key.key,
+				RetryAfterSeconds: retryAfter.Seconds(),
+			}
+		}
+	}
+
+	if rejected != nil {
+		return nil, rejected
+	}
+
+	for _, state := range states {
+		if state.limit.Rate > 0 {
+			state.tokens--
+		}
+
+		state.inFlight++
+	}
+
+	return func() {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+
+		for _, state := range states {
+			state.inFlight--
+		}
+	}, nil
+}
+
+// prune forget the idle limiters, at most once per limiterPruneInterval
+func (rl *rateLimiter) prune(now time.Time) {
+	if now.Sub(rl.pruned) < limiterPruneInterval {
+		return
+	}
+
+	rl.pruned = now
+
+	for key, state := range rl.limiters {
+		state.refill(now)
+
+		if state.idle() {
+			delete(rl.limiters, key)
+		}
+	}
+}
+
+// rateLimitedResult create the error result of a rejected call, its
+// structured content being rejected
+func rateLimitedResult(rejected *RateLimited) *mcp.CallToolResult {
+	result := TextContentError(errors.New(rejected.Error))
+	result.StructuredContent = rejected
+
+	return result
+}
+
+// RateLimitMiddleware reject the calls of Tool.Exec exceeding limits with an
+// error result whose structured content is a RateLimited telling when to
+// retry. The limits are shared by all the tools the middleware wraps.
+func RateLimitMiddleware(limits RateLimits) Middleware {
+	rl := newRateLimiter(limits)
+
+	return func(tool Tool) Tool {
+		return WrapExec(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var (
+				sessionID        string
+				configuration, _ = request.GetArguments()[argumentConfiguration].(string)
+			)
+
+			if session := server.ClientSessionFromContext(ctx); session != nil {
+				sessionID = session.SessionID()
+			}
+
+			release, rejected := rl.acquire(rl.limitsOf(tool.Name(), sessionID, configuration))
+			if rejected != nil {
+				return rateLimitedResult(rejected), nil
+			}
+			defer release()
+
+			return tool.Exec(ctx, request)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession is a server.ClientSession identified by its ID
+type fakeSession string
+
+func (fakeSession) Initialize() {}
+
+func (fakeSession) Initialized() bool {
+	return true
+}
+
+func (fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+
+func (session fakeSession) SessionID() string {
+	return string(session)
+}
+
+// contextWithSession return a context of a call made in the session
+func contextWithSession(ctx context.Context, sessionID string) context.Context {
+	return server.NewMCPServer("test", "1.0.0").WithContext(ctx, fakeSession(sessionID))
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	var (
+		rl     = newRateLimiter(RateLimits{})
+		now    = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		limits = map[limiterKey]Limit{
+			{scope: RateLimitScopeTool, key: fakeToolName}: {Rate: 2, Burst: 2},
+		}
+	)
+
+	rl.now = func() time.Time {
+		return now
+	}
+
+	tests := []struct {
+		name           string
+		advance        time.Duration
+		wantRetryAfter time.Duration
+	}{
+		{name: "burst"},
+		{name: "burst again"},
+		{name: "empty bucket", wantRetryAfter: 500 * time.Millisecond},
+		{name: "partially refilled", advance: 200 * time.Millisecond, wantRetryAfter: 300 * time.Millisecond},
+		{name: "refilled", advance: 500 * time.Millisecond},
+		{name: "idle", advance: time.Hour},
+		{name: "capped by burst"},
+		{name: "capped by burst again", wantRetryAfter: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+
+		release, rejected := rl.acquire(limits)
+		if tt.wantRetryAfter == 0 {
+			require.Nil(t, rejected, tt.name)
+			release()
+
+			continue
+		}
+
+		require.NotNil(t, rejected, tt.name)
+		assert.Equal(t, RateLimitScopeTool, rejected.Scope, tt.name)
+		assert.Equal(t, fakeToolName, rejected.Key, tt.name)
+		assert.InDelta(t, tt.wantRetryAfter.Seconds(), rejected.RetryAfterSeconds, 0.001, tt.name)
+	}
+}
+
+func TestRateLimiterAllOrNone(t *testing.T) {
+	var (
+		rl       = newRateLimiter(RateLimits{})
+		toolKey  = limiterKey{scope: RateLimitScopeTool, key: fakeToolName}
+		emptyKey = limiterKey{scope: RateLimitScopeConfiguration, key: "production"}
+	)
+
+	release, rejected := rl.acquire(map[limiterKey]Limit{emptyKey: {Rate: 0.001}})
+	require.Nil(t, rejected)
+	release()
+
+	_, rejected = rl.acquire(map[limiterKey]Limit{toolKey: {Rate: 0.001}, emptyKey: {Rate: 0.001}})
+	require.NotNil(t, rejected)
+	assert.Equal(t, RateLimitScopeConfiguration, rejected.Scope)
+
+	// the rejected call did not consume the token of the tool
+	assert.InDelta(t, 1, rl.limiters[toolKey].tokens, 0.001)
+}
+
+func TestRateLimiterPrune(t *testing.T) {
+	var (
+		rl  = newRateLimiter(RateLimits{})
+		now = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		key = limiterKey{scope: RateLimitScopeSession, key: "session"}
+	)
+
+	rl.now = func() time.Time {
+		return now
+	}
+
+	release, rejected := rl.acquire(map[limiterKey]Limit{key: {Rate: 1, MaxConcurrency: 1}})
+	require.Nil(t, rejected)
+
+	now = now.Add(2 * limiterPruneInterval)
+	_, _ = rl.acquire(nil)
+	assert.Contains(t, rl.limiters, key, "in progress")
+
+	release()
+
+	now = now.Add(2 * limiterPruneInterval)
+	_, _ = rl.acquire(nil)
+	assert.NotContains(t, rl.limiters, key)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    RateLimits
+		calls     []context.Context
+		arguments map[string]any
+		wantScope string
+	}{
+		{
+			name:      "tool",
+			limits:    RateLimits{Tools: map[string]Limit{fakeToolName: {Rate: 0.001}}},
+			calls:     []context.Context{t.Context(), t.Context()},
+			wantScope: RateLimitScopeTool,
+		},
+		{
+			name:      "other tool",
+			limits:    RateLimits{Tools: map[string]Limit{"other": {Rate: 0.001}}},
+			calls:     []context.Context{t.Context(), t.Context()},
+			wantScope: "",
+		},
+		{
+			name:      "same session",
+			limits:    RateLimits{Session: &Limit{Rate: 0.001}},
+			calls:     []context.Context{contextWithSession(t.Context(), "a"), contextWithSession(t.Context(), "a")},
+			wantScope: RateLimitScopeSession,
+		},
+		{
+			name:      "other session",
+			limits:    RateLimits{Session: &Limit{Rate: 0.001}},
+			calls:     []context.Context{contextWithSession(t.Context(), "a"), contextWithSession(t.Context(), "b")},
+			wantScope: "",
+		},
+		{
+			name:      "configuration",
+			limits:    RateLimits{Configurations: map[string]Limit{"production": {Rate: 0.001}}},
+			calls:     []context.Context{t.Context(), t.Context()},
+			arguments: map[string]any{argumentConfiguration: "production"},
+			wantScope: RateLimitScopeConfiguration,
+		},
+		{
+			name:      "other configuration",
+			limits:    RateLimits{Configurations: map[string]Limit{"production": {Rate: 0.001}}},
+			calls:     []context.Context{t.Context(), t.Context()},
+			arguments: map[string]any{argumentConfiguration: "staging"},
+			wantScope: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				tool    = Chain(newFakeTool(mcp.NewToolResultText("ok"), nil), RateLimitMiddleware(tt.limits))
+				request = mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: tt.arguments}}
+				result  *mcp.CallToolResult
+				err     error
+			)
+
+			for _, ctx := range tt.calls {
+				result, err = tool.Exec(ctx, request)
+				require.NoError(t, err)
+			}
+
+			if tt.wantScope == "" {
+				assert.Equal(t, "ok", resultText(t, result))
+				return
+			}
+
+			assert.True(t, result.IsError)
+			assert.Contains(t, resultText(t, result), "rate limited by "+tt.wantScope)
+			assert.Contains(t, resultText(t, result), "retry after")
+
+			rejected, isRateLimited := result.StructuredContent.(*RateLimited)
+			require.True(t, isRateLimited)
+			assert.Equal(t, tt.wantScope, rejected.Scope)
+			assert.Positive(t, rejected.RetryAfterSeconds)
+		})
+	}
+}
+
+func TestRateLimitMiddlewareConcurrency(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		tool    = Chain(&fakeTool{
+			exec: func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				started <- struct{}{}
+				<-release
+
+				return mcp.NewToolResultText("ok"), nil
+			},
+		}, RateLimitMiddleware(RateLimits{Tools: map[string]Limit{fakeToolName: {MaxConcurrency: 1}}}))
+		done = make(chan *mcp.CallToolResult)
+	)
+
+	go func() {
+		result, _ := tool.Exec(t.Context(), mcp.CallToolRequest{})
+		done <- result
+	}()
+
+	<-started
+
+	result, err := tool.Exec(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	assert.Equal(t, concurrencyRetryAfter.Seconds(), result.StructuredContent.(*RateLimited).RetryAfterSeconds)
+
+	close(release)
+	assert.Equal(t, "ok", resultText(t, <-done))
+
+	go func() {
+		<-started
+	}()
+
+	result, err = tool.Exec(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resultText(t, result))
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+)
+
+// defaultWatchInterval is how often ConfigurationWatcher check its files
+const defaultWatchInterval = 5 * time.Second
+
+// Configurations are named configurations, like the ones of config.Load,
+// swapped atomically when reloaded by a ConfigurationWatcher. Tools read them
+// with Resources on each call instead of keeping the map.
+type Configurations[T any] struct {
+	resources atomic.Pointer[map[string]*T]
+}
+
+// NewConfigurations create Configurations of resources
+func NewConfigurations[T any](resources map[string]*T) *Configurations[T] {
+	configurations := &Configurations[T]{}
+	configurations.resources.Store(&resources)
+
+	return configurations
+}
+
+// Resources return the current configurations, to be given to
+// WithConfigurationOption and SelectFromConfiguration. The map must not be
+// modified.
+func (configurations *Configurations[T]) Resources() map[string]*T {
+	return *configurations.resources.Load()
+}
+
+// swap the configurations for resources, returning the previous ones
+func (configurations *Configurations[T]) swap(resources map[string]*T) map[string]*T {
+	return *configurations.resources.Swap(&resources)
+}
+
+// ConfigurationWatcher reload Configurations when their files change and
+// register again the tools selecting them. The clients are notified with
+// notifications/tools/list_changed only when Server declare the listChanged
+// capability of the tools, see server.WithToolCapabilities(true).
+type ConfigurationWatcher[T any] struct {
+	// Configurations swapped when reloaded
+	Configurations *Configurations[T]
+	// Load the configurations, like config.Load of Files
+	Load func() (map[string]*T, error)
+	// Files watched, like a configuration file or an env file
+	Files []string
+	// Interval between checks of the files, 5 seconds when zero
+	Interval time.Duration
+	// Server the Tools are registered again on, wrapped by Middlewares like
+	// ServerAddTools does
+	Server      *server.MCPServer
+	Tools       []Tool
+	Middlewares []Middleware
+
+	// mu serialize the reloads
+	mu sync.Mutex
+}
+
+// Reload load the configurations and swap them, registering the Tools again
+// when their names or descriptions changed
+func (watcher *ConfigurationWatcher[T]) Reload() error {
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+
+	resources, err := watcher.Load()
+	if err != nil {
+		return errors.Wrap(err, "Load")
+	}
+
+	previous := watcher.Configurations.swap(resources)
+
+	if maps.Equal(configurationDescriptions(previous), configurationDescriptions(resources)) {
+		return nil
+	}
+
+	serverTools := make([]server.ServerTool, len(watcher.Tools))
+
+	for index, tool := range watcher.Tools {
+		serverTool, err := newServerTool(tool, watcher.Middlewares)
+		if err != nil {
+			// the tools keep the definition of the previous configurations
+			watcher.Configurations.swap(previous)
+
+			return errors.Wrapf(err, "tools[%d:%s]", index, tool.Name())
+		}
+
+		serverTools[index] = *serverTool
+	}
+
+	// a single notifications/tools/list_changed for all the tools, when the
+	// server declare the listChanged capability of the tools
+	watcher.Server.AddTools(serverTools...)
+
+	return nil
+}
+
+// Watch Reload the configurations when their files change, until ctx is done.
+// The failures are reported to the otel handler.
+func (watcher *ConfigurationWatcher[T]) Watch(ctx context.Context) error {
+	versions, err := statFiles(watcher.Files)
+	if err != nil {
+		return errors.Wrap(err, "statFiles")
+	}
+
+	// not to miss a change made since the configurations were loaded
+	if err = watcher.Reload(); err != nil {
+		otel.Handle(errors.Wrap(err, "reloading configurations"))
+	}
+
+	interval := watcher.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := statFiles(watcher.Files)
+		if err != nil {
+			otel.Handle(errors.Wrap(err, "watching configurations"))
+
+			continue
+		}
+
+		if slices.EqualFunc(current, versions, fileVersion.equal) {
+			continue
+		}
+
+		versions = current
+
+		if err = watcher.Reload(); err != nil {
+			otel.Handle(errors.Wrap(err, "reloading configurations"))
+		}
+	}
+}
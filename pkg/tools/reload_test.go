@@ -0,0 +1,250 @@
+package tools
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/transform-ia/mcp-tools/pkg/tools/config"
+)
+
+const (
+	backendToolName = "backend"
+	brokenBackend   = "broken"
+)
+
+// backend is the configuration of the backend tool
+type backend struct {
+	Address     string `yaml:"address"`
+	Description string `yaml:"description"`
+}
+
+func (configuration backend) Describe() string {
+	return configuration.Description
+}
+
+// newBackendTool create a Tool replying with the address of the backend
+// selected among configurations
+func newBackendTool(configurations *Configurations[backend]) *fakeTool {
+	return &fakeTool{
+		name: backendToolName,
+		options: func() ([]mcp.ToolOption, error) {
+			resources := configurations.Resources()
+			if _, exists := resources[brokenBackend]; exists {
+				return nil, errors.New("broken backend")
+			}
+
+			return []mcp.ToolOption{WithConfigurationOption(resources)}, nil
+		},
+		exec: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			selected, err := SelectFromConfigurationContext(ctx, configurations.Resources(), &request)
+			if err != nil {
+				return TextContentError(err), nil
+			}
+
+			return mcp.NewToolResultText(selected.Address), nil
+		},
+	}
+}
+
+// writeBackends write the backends file content, dated modTime so that each
+// write is seen as a change
+func writeBackends(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// newBackendWatcher create a watcher of the backends file path, serving
+// the backend tool on srv
+func newBackendWatcher(t *testing.T, srv *server.MCPServer, path string) *ConfigurationWatcher[backend] {
+	t.Helper()
+
+	load := func() (map[string]*backend, error) {
+		return config.Load[backend](config.WithFile(path))
+	}
+
+	resources, err := load()
+	require.NoError(t, err)
+
+	configurations := NewConfigurations(resources)
+	tools := []Tool{newBackendTool(configurations)}
+
+	require.NoError(t, ServerAddTools(srv, tools))
+
+	return &ConfigurationWatcher[backend]{
+		Configurations: configurations,
+		Load:           load,
+		Files:          []string{path},
+		Interval:       10 * time.Millisecond,
+		Server:         srv,
+		Tools:          tools,
+	}
+}
+
+// callBackend call the backend tool with configuration and return its text
+func callBackend(t *testing.T, cli *client.Client, configuration string) string {
+	t.Helper()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = backendToolName
+	request.Params.Arguments = map[string]any{argumentConfiguration: configuration}
+
+	result, err := cli.CallTool(t.Context(), request)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+// backendEnum return the configuration enum of the backend tool listed by cli
+func backendEnum(t *testing.T, cli *client.Client) []any {
+	t.Helper()
+
+	result, err := cli.ListTools(t.Context(), mcp.ListToolsRequest{})
+	require.NoError(t, err)
+	require.Len(t, result.Tools, 1)
+
+	property, isMap := result.Tools[0].InputSchema.Properties[argumentConfiguration].(map[string]any)
+	require.True(t, isMap)
+
+	enum, isSlice := property["enum"].([]any)
+	require.True(t, isSlice)
+
+	return enum
+}
+
+func TestConfigurationWatcher(t *testing.T) {
+	var (
+		path    = filepath.Join(t.TempDir(), "backends.yaml")
+		modTime = time.Now().Add(-time.Hour)
+		srv     = server.NewMCPServer("test", "1.0.0")
+		changed = make(chan struct{}, 10)
+	)
+
+	writeBackends(t, path, "production:\n  address: prod.example.com\n", modTime)
+
+	watcher := newBackendWatcher(t, srv, path)
+
+	cli, err := client.NewSSEMCPClient(startHTTPTransport(t, srv, TransportSSE) + "/sse")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	cli.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationToolsListChanged {
+			changed <- struct{}{}
+		}
+	})
+
+	initializeClient(t, cli)
+	assert.Equal(t, []any{"production"}, backendEnum(t, cli))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	watched := make(chan error, 1)
+
+	go func() {
+		watched <- watcher.Watch(ctx)
+	}()
+
+	defer func() {
+		cancel()
+		assert.NoError(t, <-watched)
+	}()
+
+	// a new configuration register the tool again
+	writeBackends(t, path, "production:\n  address: prod.example.com\nstaging:\n  address: staging.example.com\n",
+		modTime.Add(time.Second))
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "tools list not changed")
+	}
+
+	assert.ElementsMatch(t, []any{"production", "staging"}, backendEnum(t, cli))
+	assert.Equal(t, "staging.example.com", callBackend(t, cli, "staging"))
+
+	// a changed configuration is swapped
+	writeBackends(t, path, "production:\n  address: new.example.com\nstaging:\n  address: staging.example.com\n",
+		modTime.Add(2*time.Second))
+
+	assert.Eventually(t, func() bool {
+		return callBackend(t, cli, "production") == "new.example.com"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// an invalid file keep the configurations
+	writeBackends(t, path, "production: [", modTime.Add(3*time.Second))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "new.example.com", callBackend(t, cli, "production"))
+}
+
+func TestConfigurationWatcherReload(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantErr       bool
+		wantResources []string
+		wantDescribed bool
+	}{
+		{name: "same names", content: "production:\n  address: new.example.com\n", wantResources: []string{"production"}},
+		{name: "new name", content: "production: {}\nstaging: {}\n", wantResources: []string{"production", "staging"}},
+		{
+			name:          "new description",
+			content:       "production:\n  description: Production backend\n",
+			wantResources: []string{"production"},
+			wantDescribed: true,
+		},
+		{name: "invalid file", content: "production: [", wantErr: true, wantResources: []string{"production"}},
+		{name: "invalid tool", content: "broken: {}\n", wantErr: true, wantResources: []string{"production"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				path = filepath.Join(t.TempDir(), "backends.yaml")
+				srv  = server.NewMCPServer("test", "1.0.0")
+			)
+
+			writeBackends(t, path, "production:\n  address: prod.example.com\n", time.Now())
+
+			watcher := newBackendWatcher(t, srv, path)
+
+			writeBackends(t, path, tt.content, time.Now())
+
+			err := watcher.Reload()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.ElementsMatch(t, tt.wantResources, slices.Collect(maps.Keys(watcher.Configurations.Resources())))
+
+			// the registered tool describe the configurations
+			property, isMap := srv.GetTool(backendToolName).Tool.InputSchema.Properties[argumentConfiguration].(map[string]any)
+			require.True(t, isMap)
+			assert.Equal(t, tt.wantDescribed, property["oneOf"] != nil)
+		})
+	}
+}
+
+func TestConfigurationWatcherMissingFile(t *testing.T) {
+	watcher := &ConfigurationWatcher[backend]{Files: []string{filepath.Join(t.TempDir(), "missing.yaml")}}
+
+	require.Error(t, watcher.Watch(t.Context()))
+}
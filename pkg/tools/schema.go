@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/invopop/jsonschema"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+)
+
+const schemaTypeObject = "object"
+
+// tagValue decode a value of a `mcp` tag as valueType
+func tagValue(text string, valueType reflect.Type) (any, error) {
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+
+	value := reflect.New(valueType).Elem()
+	if err := decodeDefault(text, value); err != nil {
+		return nil, errors.Wrap(err, "decodeDefault")
+	}
+
+	return value.Interface(), nil
+}
+
+// elementType return the type of the items of slices, arrays and pointers
+func elementType(valueType reflect.Type) reflect.Type {
+	for {
+		switch valueType.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			valueType = valueType.Elem()
+		default:
+			return valueType
+		}
+	}
+}
+
+// itemsSchema return the schema of the items of a slice or array property
+func itemsSchema(property *jsonschema.Schema) *jsonschema.Schema {
+	for property.Items != nil {
+		property = property.Items
+	}
+
+	return property
+}
+
+// applyFieldOptions set on a property the options of the `mcp` tag of its field
+func applyFieldOptions(property *jsonschema.Schema, field reflect.StructField, options *fieldOptions) error {
+	if len(options.title) != 0 {
+		property.Title = options.title
+	}
+
+	if len(options.description) != 0 {
+		property.Description = options.description
+	}
+
+	if options.defaultValue != nil {
+		defaultValue, err := tagValue(*options.defaultValue, field.Type)
+		if err != nil {
+			return errors.Wrapf(err, "field %s: invalid default", field.Name)
+		}
+
+		property.Default = defaultValue
+	}
+
+	// enum, minimum and maximum apply to each item of an array
+	var (
+		items    = itemsSchema(property)
+		itemType = elementType(field.Type)
+	)
+
+	for _, enum := range options.enum {
+		value, err := tagValue(enum, itemType)
+		if err != nil {
+			return errors.Wrapf(err, "field %s: invalid enum", field.Name)
+		}
+
+		items.Enum = append(items.Enum, value)
+	}
+
+	if options.minimum != nil {
+		items.Minimum = json.Number(strconv.FormatFloat(*options.minimum, 'f', -1, 64))
+	}
+
+	if options.maximum != nil {
+		items.Maximum = json.Number(strconv.FormatFloat(*options.maximum, 'f', -1, 64))
+	}
+
+	return nil
+}
+
+// applyStructOptions set on an object schema the `mcp` tags of structType
+func applyStructOptions(schema *jsonschema.Schema, structType reflect.Type) error {
+	for index := range structType.NumField() {
+		field := structType.Field(index)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && len(field.Tag.Get(tagJSON)) == 0 {
+			if err := applyStructOptions(schema, field.Type); err != nil {
+				return errors.Wrap(err, field.Name)
+			}
+
+			continue
+		}
+
+		options, err := parseFieldOptions(field)
+		if err != nil {
+			return errors.Wrap(err, "parseFieldOptions")
+		}
+
+		if options == nil || schema.Properties == nil {
+			continue
+		}
+
+		property, exists := schema.Properties.Get(options.name)
+		if !exists {
+			continue
+		}
+
+		if err = applyFieldOptions(property, field, options); err != nil {
+			return errors.Wrap(err, "applyFieldOptions")
+		}
+
+		if options.required {
+			schema.Required = append(schema.Required, options.name)
+		}
+
+		if itemType := elementType(field.Type); itemType.Kind() == reflect.Struct {
+			if err = applyStructOptions(itemsSchema(property), itemType); err != nil {
+				return errors.Wrap(err, field.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// reflectSchema generate the JSON schema of structType with the options of
+// its `mcp` tags, the same ones used by BindArguments
+func reflectSchema(structType reflect.Type) (*mcp.ToolArgumentsSchema, error) {
+	if structType.Kind() != reflect.Struct {
+		return nil, errors.Errorf("cannot generate schema of %s - must be a struct", structType)
+	}
+
+	reflector := jsonschema.Reflector{
+		// inline every type instead of using $defs
+		DoNotReference:            true,
+		Anonymous:                 true,
+		AllowAdditionalProperties: true,
+		// required fields are declared by `mcp` tags, not by omitempty
+		RequiredFromJSONSchemaTags: true,
+	}
+
+	schema := reflector.ReflectFromType(structType)
+	schema.Version = ""
+
+	if err := applyStructOptions(schema, structType); err != nil {
+		return nil, errors.Wrap(err, "applyStructOptions")
+	}
+
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal")
+	}
+
+	var output mcp.ToolArgumentsSchema
+	if err = json.Unmarshal(encoded, &output); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+
+	output.Type = schemaTypeObject
+
+	if output.Properties == nil {
+		output.Properties = make(map[string]any)
+	}
+
+	return &output, nil
+}
+
+// NewTypedTool create a tool whose input schema is generated from the struct
+// In and, if Out is a struct, output schema from Out. Struct tags are the ones
+// of BindArguments, so the schema and the binding of arguments can't drift.
+// options are applied after the schemas, like WithConfigurationOption.
+func NewTypedTool[In, Out any](name string, options ...mcp.ToolOption) (*mcp.Tool, error) {
+	inputSchema, err := reflectSchema(reflect.TypeFor[In]())
+	if err != nil {
+		return nil, errors.Wrap(err, "reflectSchema(In)")
+	}
+
+	tool := mcp.NewTool(name)
+	tool.InputSchema = mcp.ToolInputSchema(*inputSchema)
+
+	outputType := reflect.TypeFor[Out]()
+	for outputType.Kind() == reflect.Pointer {
+		outputType = outputType.Elem()
+	}
+
+	if outputType.Kind() == reflect.Struct {
+		outputSchema, err := reflectSchema(outputType)
+		if err != nil {
+			return nil, errors.Wrap(err, "reflectSchema(Out)")
+		}
+
+		tool.OutputSchema = mcp.ToolOutputSchema(*outputSchema)
+	}
+
+	for _, option := range options {
+		option(&tool)
+	}
+
+	return &tool, nil
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaResult struct {
+	Count int      `json:"count" mcp:"description=Number of matches"`
+	Names []string `json:"names"`
+}
+
+func TestNewTypedTool(t *testing.T) {
+	tool, err := NewTypedTool[bindArguments, schemaResult](
+		"search",
+		mcp.WithDescription("Search things"),
+		WithConfigurationOption(map[string]*int{"one": new(int)}),
+	)
+	require.NoError(t, err)
+
+	encoded, err := json.Marshal(tool)
+	require.NoError(t, err)
+
+	var got struct {
+		Name         string         `json:"name"`
+		Description  string         `json:"description"`
+		InputSchema  map[string]any `json:"inputSchema"`
+		OutputSchema map[string]any `json:"outputSchema"`
+	}
+
+	require.NoError(t, json.Unmarshal(encoded, &got))
+	assert.Equal(t, "search", got.Name)
+	assert.Equal(t, "Search things", got.Description)
+
+	var want map[string]any
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["query", "configuration"],
+		"properties": {
+			"limit": {"type": "integer", "minimum": 1, "maximum": 100, "default": 10},
+			"query": {"type": "string", "description": "Text to search, case insensitive"},
+			"order": {"type": "string", "enum": ["asc", "desc"], "default": "asc"},
+			"score": {"type": "number"},
+			"verbose": {"type": "boolean"},
+			"levels": {"type": "array", "items": {"type": "integer", "enum": [1, 2, 3]}},
+			"filters": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["field"],
+					"properties": {
+						"field": {"type": "string"},
+						"values": {"type": "array", "items": {"type": "string"}}
+					}
+				}
+			},
+			"configuration": {
+				"type": "string",
+				"title": "Configuration name",
+				"description": "Which configuration use to perform MCP server operations",
+				"enum": ["one"],
+				"default": "one"
+			}
+		}
+	}`), &want))
+	assert.Equal(t, want, got.InputSchema)
+
+	var wantOutput map[string]any
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"count": {"type": "integer", "description": "Number of matches"},
+			"names": {"type": "array", "items": {"type": "string"}}
+		}
+	}`), &wantOutput))
+	assert.Equal(t, wantOutput, got.OutputSchema)
+}
+
+func TestNewTypedToolWithoutOutputSchema(t *testing.T) {
+	tool, err := NewTypedTool[bindPaging, string]("paging")
+	require.NoError(t, err)
+
+	assert.Empty(t, tool.OutputSchema.Type)
+	assert.Equal(t, []string{"limit"}, keys(tool.InputSchema.Properties))
+}
+
+func TestNewTypedToolInvalid(t *testing.T) {
+	_, err := NewTypedTool[string, string]("invalid")
+	require.Error(t, err)
+
+	type invalidEnum struct {
+		Level int `json:"level" mcp:"enum=high"`
+	}
+
+	_, err = NewTypedTool[invalidEnum, string]("invalid")
+	require.Error(t, err)
+}
+
+func keys(properties map[string]any) []string {
+	output := make([]string, 0, len(properties))
+
+	for key := range properties {
+		output = append(output, key)
+	}
+
+	return output
+}
@@ -1,16 +1,17 @@
 package tools
 
 import (
-	"fmt"
+	"context"
 	"maps"
 	"net/url"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	"github.com/pkg/errors"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
 )
 
 // MustGetenvErrorFormat is a convenient error wrapper format
@@ -31,6 +32,9 @@ func MustGetenv(keyName string) (*string, error) {
 
 // GetEnvironmentURLS compile a list of url made from environment
 // variables that have a common prefix
+//
+// Deprecated: use config.Load with config.Endpoint, which also read a file
+// and command line flags.
 func GetEnvironmentURLS(prefix string) (map[string]*url.URL, error) {
 	output := make(map[string]*url.URL)
 
@@ -51,46 +55,147 @@ func GetEnvironmentURLS(prefix string) (map[string]*url.URL, error) {
 	return output, nil
 }
 
-// WithConfigurationOption create a tool property to select a configuration key
-func WithConfigurationOption[T any](resources map[string]*T) mcp.ToolOption {
+// Describer is implemented by the configurations describing themselves to the
+// model in the property of WithConfigurationOption
+type Describer interface {
+	Describe() string
+}
+
+// ConfigurationPropertyOption configure the property of WithConfigurationOption
+type ConfigurationPropertyOption func(*configurationProperty)
+
+// configurationProperty is the configuration of WithConfigurationOption
+type configurationProperty struct {
+	// defaultName of the configuration, the only one when empty
+	defaultName string
+}
+
+// WithDefaultConfiguration advertise name as the default configuration, even
+// when there are several of them. It is ignored when name is not one of them.
+func WithDefaultConfiguration(name string) ConfigurationPropertyOption {
+	return func(property *configurationProperty) {
+		property.defaultName = name
+	}
+}
+
+// WithConfigurationOption create a tool property to select a configuration
+// key. The keys are sorted, so that the schema is stable, and the
+// configurations implementing Describer are described by a oneOf entry.
+func WithConfigurationOption[T any](
+	resources map[string]*T,
+	opts ...ConfigurationPropertyOption,
+) mcp.ToolOption {
 	const (
 		title       = "Configuration name"
 		description = "Which configuration use to perform MCP server operations"
 	)
 
 	var (
-		lenMap = len(resources)
-		keys   = make([]string, lenMap)
-		index  = 0
+		property = configurationProperty{}
+		keys     = slices.Sorted(maps.Keys(resources))
 	)
 
-	for key := range maps.Keys(resources) {
-		keys[index] = key
-		index++
+	for _, opt := range opts {
+		opt(&property)
 	}
 
-	if lenMap == 1 {
-		return mcp.WithString(
-			argumentConfiguration,
-			mcp.Required(),
-			mcp.Title(title),
-			mcp.Description(description),
-			mcp.DefaultString(keys[0]),
-			mcp.Enum(keys...),
-		)
+	if property.defaultName == "" && len(keys) == 1 {
+		property.defaultName = keys[0]
 	}
 
-	return mcp.WithString(
-		argumentConfiguration,
+	propertyOptions := []mcp.PropertyOption{
 		mcp.Required(),
 		mcp.Title(title),
 		mcp.Description(description),
-		mcp.Enum(keys...),
+	}
+
+	if _, exists := resources[property.defaultName]; exists {
+		propertyOptions = append(propertyOptions, mcp.DefaultString(property.defaultName))
+	}
+
+	propertyOptions = append(propertyOptions, mcp.Enum(keys...))
+
+	if oneOf := describeConfigurations(resources, keys); oneOf != nil {
+		propertyOptions = append(propertyOptions, func(schema map[string]any) {
+			schema["oneOf"] = oneOf
+		})
+	}
+
+	return mcp.WithString(argumentConfiguration, propertyOptions...)
+}
+
+// configurationNames return the configuration names of the definition of a
+// tool, created with WithConfigurationOption maybe wrapped by FanOutMiddleware
+func configurationNames(instance *mcp.Tool) []string {
+	property, _ := instance.InputSchema.Properties[argumentConfiguration].(map[string]any)
+
+	if anyOf, isSlice := property["anyOf"].([]any); isSlice && len(anyOf) > 0 {
+		property, _ = anyOf[0].(map[string]any)
+	}
+
+	names, _ := property["enum"].([]string)
+
+	return names
+}
+
+// describeConfigurations return a oneOf entry titled by key for each
+// configuration, described by configurationDescriptions. It is nil when none
+// is described.
+func describeConfigurations[T any](resources map[string]*T, keys []string) []any {
+	var (
+		descriptions = configurationDescriptions(resources)
+		oneOf        = make([]any, len(keys))
+		described    bool
 	)
+
+	for index, key := range keys {
+		entry := map[string]any{"const": key, "title": key}
+
+		if descriptions[key] != "" {
+			entry["description"] = descriptions[key]
+			described = true
+		}
+
+		oneOf[index] = entry
+	}
+
+	if !described {
+		return nil
+	}
+
+	return oneOf
+}
+
+// configurationDescriptions return the description of each configuration,
+// empty when it does not implement Describer
+func configurationDescriptions[T any](resources map[string]*T) map[string]string {
+	descriptions := make(map[string]string, len(resources))
+
+	for key, resource := range resources {
+		if describer, isDescriber := any(resource).(Describer); isDescriber && resource != nil {
+			descriptions[key] = describer.Describe()
+		} else {
+			descriptions[key] = ""
+		}
+	}
+
+	return descriptions
 }
 
-// SelectFromConfiguration get the value of something based on MCP server request
+// SelectFromConfiguration get the value of something based on MCP server request.
+// The secret.Secret fields of the value are resolved on first selection, see
+// SelectFromConfigurationContext to resolve them within the call context.
 func SelectFromConfiguration[T any](resources map[string]*T, request *mcp.CallToolRequest) (*T, error) {
+	return SelectFromConfigurationContext(context.Background(), resources, request)
+}
+
+// SelectFromConfigurationContext is SelectFromConfiguration resolving the
+// secrets within ctx, the one of the call
+func SelectFromConfigurationContext[T any](
+	ctx context.Context,
+	resources map[string]*T,
+	request *mcp.CallToolRequest,
+) (*T, error) {
 	config, err := GetParam[string](request, argumentConfiguration)
 	if err != nil {
 		return nil, errors.Wrap(err, GetParamError)
@@ -101,11 +206,18 @@ func SelectFromConfiguration[T any](resources map[string]*T, request *mcp.CallTo
 		return nil, errors.Errorf("invalid configuration %q", *config)
 	}
 
+	if err = secret.ResolveAll(ctx, value); err != nil {
+		return nil, errors.Wrapf(err, "configuration %q", *config)
+	}
+
 	return value, nil
 }
 
 // GetEnvironmentStrings compile a list of string made from environment
 // variables that have a common prefix
+//
+// Deprecated: use config.Load, which decode typed configurations from
+// environment variables, a file and command line flags.
 func GetEnvironmentStrings(prefix string) (map[string]string, error) {
 	var (
 		output           = make(map[string]string)
@@ -113,12 +225,10 @@ func GetEnvironmentStrings(prefix string) (map[string]string, error) {
 	)
 
 	for _, env := range os.Environ() {
-		key := strings.Split(env, "=")[0]
-
-		if strings.HasPrefix(key, prefixUnderscore) {
-			name, _ := strings.CutPrefix(key, prefixUnderscore)
+		key, value, _ := strings.Cut(env, "=")
 
-			output[name] = os.Getenv(key)
+		if name, found := strings.CutPrefix(key, prefixUnderscore); found {
+			output[name] = value
 		}
 	}
 
@@ -128,40 +238,3 @@ func GetEnvironmentStrings(prefix string) (map[string]string, error) {
 
 	return output, nil
 }
-
-// Serve either server a MCP server over stdin/stdout or HTTP
-// if the environment variable PORT is defined
-func Serve(srv *server.MCPServer) error {
-	const key = "PORT"
-
-	portStr := os.Getenv(key)
-	if len(portStr) != 0 {
-		port, err := strconv.ParseUint(portStr, 10, 16)
-		if err != nil {
-			return errors.Wrapf(
-				err,
-				"invalid port number %q - must be between 1 and 65535",
-				portStr,
-			)
-		}
-		if port < 1 || port > 65535 {
-			return errors.Errorf(
-				"invalid port number %d - must be between 1 and 65535",
-				port,
-			)
-		}
-
-		if err = server.NewSSEServer(
-			srv,
-			server.WithBasePath("/"),
-		).Start(fmt.Sprintf(":%d", port)); err != nil {
-			return errors.Wrap(err, "Start")
-		}
-	}
-
-	if err := server.ServeStdio(srv); err != nil {
-		return errors.Wrap(err, "server.ServeStdio")
-	}
-
-	return nil
-}
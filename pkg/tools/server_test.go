@@ -1,13 +1,18 @@
 package tools
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+	"github.com/transform-ia/mcp-tools/pkg/tools/config"
 )
 
 func TestMustGetenv(t *testing.T) {
@@ -52,10 +57,25 @@ func TestMustGetenv(t *testing.T) {
 	}
 }
 
+// configurationPropertySchema return the configuration property of a tool
+// created with option
+func configurationPropertySchema(t *testing.T, option mcp.ToolOption) map[string]any {
+	t.Helper()
+
+	tool := mcp.NewTool("test", option)
+
+	property, isMap := tool.InputSchema.Properties[argumentConfiguration].(map[string]any)
+	require.True(t, isMap)
+	assert.Equal(t, []string{argumentConfiguration}, tool.InputSchema.Required)
+
+	return property
+}
+
 func TestWithConfigurationOption(t *testing.T) {
 	tests := []struct {
 		name        string
 		resources   map[string]*int
+		opts        []ConfigurationPropertyOption
 		wantEnum    []string
 		wantDefault string
 	}{
@@ -69,22 +89,76 @@ func TestWithConfigurationOption(t *testing.T) {
 		},
 		{
 			name: "multiple resources",
+			resources: map[string]*int{
+				"two":   new(int),
+				"one":   new(int),
+				"three": new(int),
+			},
+			wantEnum: []string{"one", "three", "two"},
+		},
+		{
+			name: "default",
+			resources: map[string]*int{
+				"one": new(int),
+				"two": new(int),
+			},
+			opts:        []ConfigurationPropertyOption{WithDefaultConfiguration("two")},
+			wantEnum:    []string{"one", "two"},
+			wantDefault: "two",
+		},
+		{
+			name: "unknown default",
 			resources: map[string]*int{
 				"one": new(int),
 				"two": new(int),
 			},
+			opts:     []ConfigurationPropertyOption{WithDefaultConfiguration("three")},
 			wantEnum: []string{"one", "two"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			opt := WithConfigurationOption(tt.resources)
-			assert.NotNil(t, opt)
+			property := configurationPropertySchema(t, WithConfigurationOption(tt.resources, tt.opts...))
+
+			assert.Equal(t, tt.wantEnum, property["enum"])
+			assert.NotContains(t, property, "oneOf")
+
+			if tt.wantDefault == "" {
+				assert.NotContains(t, property, "default")
+			} else {
+				assert.Equal(t, tt.wantDefault, property["default"])
+			}
 		})
 	}
 }
 
+// describedResource is a configuration implementing Describer
+type describedResource struct {
+	description string
+}
+
+func (resource describedResource) Describe() string {
+	return resource.description
+}
+
+func TestWithConfigurationOptionDescriber(t *testing.T) {
+	resources := map[string]*describedResource{
+		"staging":    {description: "Staging cluster"},
+		"production": {description: "Production cluster"},
+		"unset":      nil,
+	}
+
+	property := configurationPropertySchema(t, WithConfigurationOption(resources))
+
+	assert.Equal(t, []string{"production", "staging", "unset"}, property["enum"])
+	assert.Equal(t, []any{
+		map[string]any{"const": "production", "title": "production", "description": "Production cluster"},
+		map[string]any{"const": "staging", "title": "staging", "description": "Staging cluster"},
+		map[string]any{"const": "unset", "title": "unset"},
+	}, property["oneOf"])
+}
+
 func TestSelectFromConfiguration(t *testing.T) {
 	resources := map[string]*int{
 		"one": new(int),
@@ -112,13 +186,7 @@ func TestSelectFromConfiguration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := &mcp.CallToolRequest{
-				Params: struct {
-					Name      string         `json:"name"`
-					Arguments map[string]any `json:"arguments,omitempty"`
-					Meta      *struct {
-						ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
-					} `json:"_meta,omitempty"`
-				}{
+				Params: mcp.CallToolParams{
 					Arguments: map[string]any{
 						argumentConfiguration: tt.config,
 					},
@@ -138,50 +206,68 @@ func TestSelectFromConfiguration(t *testing.T) {
 	}
 }
 
-func TestServe(t *testing.T) {
+func TestSelectFromConfigurationSecrets(t *testing.T) {
+	t.Setenv("SELECT_TEST_TOKEN", "select-secret")
+
+	resources := map[string]*config.Endpoint{
+		"valid":   {Token: secret.New("env://SELECT_TEST_TOKEN")},
+		"missing": {Token: secret.New("env://SELECT_TEST_UNDEFINED")},
+	}
+
 	tests := []struct {
-		name     string
-		port     string
-		wantHTTP bool
-		wantErr  bool
+		name      string
+		config    string
+		wantToken string
+		wantErr   string
 	}{
-		{
-			name:     "stdio mode",
-			wantHTTP: false,
-		},
-		{
-			name:     "http mode",
-			port:     "8080",
-			wantHTTP: true,
-		},
-		{
-			name:    "invalid port",
-			port:    "invalid",
-			wantErr: true,
-		},
+		{name: "resolved", config: "valid", wantToken: "select-secret"},
+		{name: "unresolved", config: "missing", wantErr: `configuration "missing"`},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			os.Clearenv()
-			if tt.port != "" {
-				t.Setenv("PORT", tt.port)
+			req := &mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Arguments: map[string]any{
+						argumentConfiguration: tt.config,
+					},
+				},
 			}
 
-			// Create a mock server
-			srv := &server.MCPServer{}
-
-			// In real tests, we'd need to mock the server.Start() method
-			// For now just test the basic behavior
-			err := Serve(srv)
-			if tt.wantErr {
-				require.Error(t, err)
+			got, err := SelectFromConfiguration(resources, req)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
 				return
 			}
 
 			require.NoError(t, err)
+			assert.Equal(t, tt.wantToken, got.Token.Value())
+
+			// the resolved secret is redacted from the errors
+			result := TextContentError(errors.Errorf("invalid token %s", got.Token.Value()))
+			assert.Equal(t, `Error: "invalid token [REDACTED]"`, result.Content[0].(mcp.TextContent).Text)
 		})
 	}
 }
 
-// Existing tests for GetEnvironmentStrings and GetEnvironmentURLS...
+func TestSelectFromConfigurationContext(t *testing.T) {
+	resources := map[string]*config.Endpoint{
+		"slow": {Token: secret.New("exec://sleep 5")},
+	}
+
+	req := &mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: map[string]any{
+				argumentConfiguration: "slow",
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	start := time.Now()
+	_, err := SelectFromConfigurationContext(ctx, resources, req)
+	require.ErrorContains(t, err, `configuration "slow"`)
+	assert.Less(t, time.Since(start), time.Second)
+}
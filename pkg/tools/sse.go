@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// sseMessageEvent prefix the events of the SSE transport carrying a message
+	sseMessageEvent = "event: message\ndata: "
+	// maxSSEMessageSize is the size of the largest message posted to a session
+	maxSSEMessageSize = 4 << 20
+)
+
+// jsonRPCMessage is the part of a JSON-RPC message telling its kind
+type jsonRPCMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// hasID tell whether message is a request or a response, not a notification
+func (message *jsonRPCMessage) hasID() bool {
+	return len(message.ID) != 0 && string(message.ID) != "null"
+}
+
+// sseResponses count the requests posted to the sessions of the SSE transport
+// until their response is sent on the stream of their session, so that the
+// sessions are only closed once the responses of the in-flight calls are sent
+type sseResponses struct {
+	ssePath, messagePath string
+	pending              *inFlight
+	// draining refuse the new sessions and requests
+	draining atomic.Bool
+
+	mutex sync.Mutex
+	// sessions count the pending responses by session ID
+	sessions map[string]int
+}
+
+// newSSEResponses create the sseResponses of the SSE endpoint at ssePath and
+// of the message endpoint at messagePath
+func newSSEResponses(ssePath, messagePath string) *sseResponses {
+	return &sseResponses{
+		ssePath:     ssePath,
+		messagePath: messagePath,
+		pending:     newInFlight(),
+		sessions:    make(map[string]int),
+	}
+}
+
+func (responses *sseResponses) add(sessionID string) {
+	responses.mutex.Lock()
+	defer responses.mutex.Unlock()
+
+	responses.sessions[sessionID]++
+	responses.pending.add()
+}
+
+// done mark count responses of the session as sent, at most the pending ones
+func (responses *sseResponses) done(sessionID string, count int) {
+	responses.mutex.Lock()
+	defer responses.mutex.Unlock()
+
+	count = min(count, responses.sessions[sessionID])
+	if responses.sessions[sessionID] -= count; responses.sessions[sessionID] == 0 {
+		delete(responses.sessions, sessionID)
+	}
+
+	for range count {
+		responses.pending.done()
+	}
+}
+
+// drain refuse the new sessions and requests, and wait until the pending
+// responses are sent or ctx is done
+func (responses *sseResponses) drain(ctx context.Context) error {
+	responses.draining.Store(true)
+
+	return responses.pending.wait(ctx)
+}
+
+// track wrap the handler of the SSE transport to count the pending responses
+func (responses *sseResponses) track(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if responses.draining.Load() {
+			http.Error(writer, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		switch request.URL.Path {
+		case responses.ssePath:
+			stream := &sseStreamWriter{ResponseWriter: writer, responses: responses}
+			defer stream.close()
+
+			handler.ServeHTTP(stream, request)
+		case responses.messagePath:
+			responses.post(writer, request, handler)
+		default:
+			handler.ServeHTTP(writer, request)
+		}
+	})
+}
+
+// post serve a message posted to a session, counting it until its response
+// is sent when it is a request accepted by the transport
+func (responses *sseResponses) post(writer http.ResponseWriter, request *http.Request, handler http.Handler) {
+	body, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxSSEMessageSize))
+	if err != nil {
+		status := http.StatusBadRequest
+		if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		http.Error(writer, http.StatusText(status), status)
+
+		return
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var message jsonRPCMessage
+	if json.Unmarshal(body, &message) != nil || message.Method == "" || !message.hasID() {
+		handler.ServeHTTP(writer, request)
+
+		return
+	}
+
+	var (
+		sessionID = request.URL.Query().Get("sessionId")
+		status    = &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+	)
+
+	responses.add(sessionID)
+	handler.ServeHTTP(status, request)
+
+	// a rejected request get no response on the stream
+	if status.status != http.StatusAccepted {
+		responses.done(sessionID, 1)
+	}
+}
+
+// statusRecorder is an http.ResponseWriter recording the status code
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// sseStreamWriter is the http.ResponseWriter of the stream of an SSE
+// session, counting the responses sent on it
+type sseStreamWriter struct {
+	http.ResponseWriter
+
+	responses *sseResponses
+	sessionID string
+}
+
+func (stream *sseStreamWriter) Write(data []byte) (int, error) {
+	written, err := stream.ResponseWriter.Write(data)
+
+	event := string(data)
+	if stream.sessionID == "" {
+		stream.sessionID = endpointSessionID(event)
+	} else if isSSEResponse(event) {
+		stream.responses.done(stream.sessionID, 1)
+	}
+
+	return written, err //nolint:wrapcheck
+}
+
+// Flush implements http.Flusher, which the SSE transport require
+func (stream *sseStreamWriter) Flush() {
+	if flusher, isFlusher := stream.ResponseWriter.(http.Flusher); isFlusher {
+		flusher.Flush()
+	}
+}
+
+// close count the responses still pending on the closed stream as sent
+func (stream *sseStreamWriter) close() {
+	if stream.sessionID != "" {
+		stream.responses.done(stream.sessionID, math.MaxInt)
+	}
+}
+
+// isSSEResponse tell whether event carry the response of a request, even
+// without ID like the internal errors of the transport: any response of a
+// session complete one of its pending requests
+func isSSEResponse(event string) bool {
+	data, found := strings.CutPrefix(event, sseMessageEvent)
+	if !found {
+		return false
+	}
+
+	var message jsonRPCMessage
+	if json.Unmarshal([]byte(data), &message) != nil {
+		return false
+	}
+
+	return message.Method == "" && (len(message.Result) != 0 || len(message.Error) != 0)
+}
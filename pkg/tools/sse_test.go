@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSSEResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  bool
+	}{
+		{name: "result", event: sseMessageEvent + `{"jsonrpc":"2.0","id":1,"result":{}}` + "\n\n", want: true},
+		{name: "error", event: sseMessageEvent + `{"jsonrpc":"2.0","id":"a","error":{"code":1}}` + "\n\n", want: true},
+		{name: "null id", event: sseMessageEvent + `{"jsonrpc":"2.0","id":null,"error":{"code":1}}` + "\n\n", want: true},
+		{name: "notification", event: sseMessageEvent + `{"jsonrpc":"2.0","method":"notifications/progress"}` + "\n\n"},
+		{name: "request", event: sseMessageEvent + `{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n\n"},
+		{name: "endpoint", event: "event: endpoint\ndata: /message?sessionId=1\r\n\r\n"},
+		{name: "invalid", event: sseMessageEvent + "{"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isSSEResponse(tt.event))
+		})
+	}
+}
+
+func TestSSEResponsesPostTooLarge(t *testing.T) {
+	var (
+		responses = newSSEResponses("/sse", "/message")
+		called    bool
+		handler   = http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })
+		recorder  = httptest.NewRecorder()
+		body      = strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping","params":"` +
+			strings.Repeat("a", maxSSEMessageSize) + `"}`)
+	)
+
+	responses.track(handler).ServeHTTP(recorder, httptest.NewRequestWithContext(
+		t.Context(), http.MethodPost, "/message?sessionId=1", body,
+	))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.False(t, called)
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
+)
+
+// Names of the telemetry of tool calls
+const (
+	instrumentationName = "github.com/transform-ia/mcp-tools/pkg/tools"
+	// MetricToolCalls count the tool calls
+	MetricToolCalls = "mcp.tool.calls"
+	// MetricToolDuration is the duration of tool calls in seconds
+	MetricToolDuration = "mcp.tool.duration"
+	// AttributeToolName is the name of the called tool
+	AttributeToolName = attribute.Key("mcp.tool.name")
+	// AttributeToolConfiguration is the configuration selected by the call
+	AttributeToolConfiguration = attribute.Key("mcp.tool.configuration")
+	// AttributeToolIsError tell if the call returned an error result
+	AttributeToolIsError = attribute.Key("mcp.tool.is_error")
+	// otherConfiguration replace in the metrics the configurations that are
+	// not defined by the tool, not to create a series for each value sent
+	otherConfiguration = "_other"
+)
+
+// configurationsTool is a Tool recording the configuration names of its
+// definition
+type configurationsTool struct {
+	Tool
+
+	names atomic.Pointer[[]string]
+}
+
+// New implements Tool
+func (tool *configurationsTool) New() (*mcp.Tool, error) {
+	instance, err := tool.Tool.New()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	names := configurationNames(instance)
+	tool.names.Store(&names)
+
+	return instance, nil
+}
+
+// known tell if configuration is one of the names of the definition
+func (tool *configurationsTool) known(configuration string) bool {
+	names := tool.names.Load()
+
+	return names != nil && slices.Contains(*names, configuration)
+}
+
+// resultErrorMessage return the text of an error result, the resolved
+// secrets being redacted
+func resultErrorMessage(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, isText := mcp.AsTextContent(content); isText {
+			return secret.Redact(text.Text)
+		}
+	}
+
+	return "tool returned an error result"
+}
+
+// TelemetryMiddleware trace each call of Tool.Exec in a span named after the
+// tool and measure it with the MetricToolCalls counter and MetricToolDuration
+// histogram. Spans and measurements carry the tool name, the configuration
+// argument used by SelectFromConfiguration and whether the result is an error.
+// The measurements carry only the configurations defined by the tool.
+// The resolved secrets are redacted from the errors (see secret.Redact).
+func TelemetryMiddleware(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) (Middleware, error) {
+	var (
+		tracer = tracerProvider.Tracer(instrumentationName)
+		meter  = meterProvider.Meter(instrumentationName)
+	)
+
+	calls, err := meter.Int64Counter(
+		MetricToolCalls,
+		metric.WithDescription("Number of MCP tool calls"),
+		metric.WithUnit("{call}"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "meter.Int64Counter")
+	}
+
+	duration, err := meter.Float64Histogram(
+		MetricToolDuration,
+		metric.WithDescription("Duration of MCP tool calls"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "meter.Float64Histogram")
+	}
+
+	return func(tool Tool) Tool {
+		defined := &configurationsTool{Tool: tool}
+
+		return WrapExec(defined, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var (
+				attributes       = []attribute.KeyValue{AttributeToolName.String(tool.Name())}
+				metricAttributes = []attribute.KeyValue{AttributeToolName.String(tool.Name())}
+			)
+
+			if configuration, isString := request.GetArguments()[argumentConfiguration].(string); isString {
+				attributes = append(attributes, AttributeToolConfiguration.String(configuration))
+
+				if !defined.known(configuration) {
+					configuration = otherConfiguration
+				}
+
+				metricAttributes = append(metricAttributes, AttributeToolConfiguration.String(configuration))
+			}
+
+			ctx, span := tracer.Start(
+				ctx,
+				tool.Name(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attributes...),
+			)
+			defer span.End()
+
+			start := time.Now()
+			result, err := tool.Exec(ctx, request)
+			elapsed := time.Since(start).Seconds()
+
+			isError := err != nil || (result != nil && result.IsError)
+
+			switch {
+			case err != nil:
+				message := secret.Redact(err.Error())
+				span.RecordError(errors.New(message))
+				span.SetStatus(codes.Error, message)
+			case isError:
+				span.SetStatus(codes.Error, resultErrorMessage(result))
+			default:
+				span.SetStatus(codes.Ok, "")
+			}
+
+			metricAttributes = append(metricAttributes, AttributeToolIsError.Bool(isError))
+			span.SetAttributes(AttributeToolIsError.Bool(isError))
+
+			calls.Add(ctx, 1, metric.WithAttributes(metricAttributes...))
+			duration.Record(ctx, elapsed, metric.WithAttributes(metricAttributes...))
+
+			return result, err
+		})
+	}, nil
+}
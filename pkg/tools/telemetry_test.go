@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newConfiguredFakeTool create a fakeTool defining the configurations names
+func newConfiguredFakeTool(names ...string) *fakeTool {
+	resources := make(map[string]*int, len(names))
+	for _, name := range names {
+		resources[name] = nil
+	}
+
+	tool := newFakeTool(mcp.NewToolResultText("ok"), nil)
+	tool.options = withOptions(WithConfigurationOption(resources))
+
+	return tool
+}
+
+func TestTelemetryMiddleware(t *testing.T) {
+	tests := []struct {
+		name                 string
+		tool                 Tool
+		arguments            map[string]any
+		wantStatus           codes.Code
+		wantAttributes       []attribute.KeyValue
+		wantMetricAttributes []attribute.KeyValue
+	}{
+		{
+			name:       "success with configuration",
+			tool:       newConfiguredFakeTool("prod"),
+			arguments:  map[string]any{argumentConfiguration: "prod"},
+			wantStatus: codes.Ok,
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolConfiguration.String("prod"),
+				AttributeToolIsError.Bool(false),
+			},
+		},
+		{
+			name:       "unknown configuration",
+			tool:       newConfiguredFakeTool("prod"),
+			arguments:  map[string]any{argumentConfiguration: "random-1234"},
+			wantStatus: codes.Ok,
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolConfiguration.String("random-1234"),
+				AttributeToolIsError.Bool(false),
+			},
+			wantMetricAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolConfiguration.String(otherConfiguration),
+				AttributeToolIsError.Bool(false),
+			},
+		},
+		{
+			name:       "error result",
+			tool:       newFakeTool(TextContentError(errors.New("invalid")), nil),
+			wantStatus: codes.Error,
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolIsError.Bool(true),
+			},
+		},
+		{
+			name:       "error",
+			tool:       newFakeTool(nil, errors.New("failure")),
+			wantStatus: codes.Error,
+			wantAttributes: []attribute.KeyValue{
+				AttributeToolName.String(fakeToolName),
+				AttributeToolIsError.Bool(true),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				recorder       = tracetest.NewSpanRecorder()
+				tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+				reader         = sdkmetric.NewManualReader()
+				meterProvider  = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+			)
+
+			middleware, err := TelemetryMiddleware(tracerProvider, meterProvider)
+			require.NoError(t, err)
+
+			tool := Chain(tt.tool, middleware)
+
+			_, err = tool.New()
+			require.NoError(t, err)
+
+			_, _ = tool.Exec(t.Context(), mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Arguments: tt.arguments},
+			})
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, fakeToolName, spans[0].Name())
+			assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+			assert.Equal(t, tt.wantStatus, spans[0].Status().Code)
+			assert.ElementsMatch(t, tt.wantAttributes, spans[0].Attributes())
+
+			var collected metricdata.ResourceMetrics
+
+			require.NoError(t, reader.Collect(t.Context(), &collected))
+			require.Len(t, collected.ScopeMetrics, 1)
+
+			metrics := make(map[string]metricdata.Metrics)
+			for _, metric := range collected.ScopeMetrics[0].Metrics {
+				metrics[metric.Name] = metric
+			}
+
+			wantSet := attribute.NewSet(tt.wantAttributes...)
+			if tt.wantMetricAttributes != nil {
+				wantSet = attribute.NewSet(tt.wantMetricAttributes...)
+			}
+
+			counter, isSum := metrics[MetricToolCalls].Data.(metricdata.Sum[int64])
+			require.True(t, isSum)
+			require.Len(t, counter.DataPoints, 1)
+			assert.Equal(t, int64(1), counter.DataPoints[0].Value)
+			assert.True(t, wantSet.Equals(&counter.DataPoints[0].Attributes))
+
+			histogram, isHistogram := metrics[MetricToolDuration].Data.(metricdata.Histogram[float64])
+			require.True(t, isHistogram)
+			require.Len(t, histogram.DataPoints, 1)
+			assert.Equal(t, uint64(1), histogram.DataPoints[0].Count)
+			assert.True(t, wantSet.Equals(&histogram.DataPoints[0].Attributes))
+		})
+	}
+}
+
+func TestTelemetryMiddlewareSpanParent(t *testing.T) {
+	var (
+		recorder       = tracetest.NewSpanRecorder()
+		tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		meterProvider  = sdkmetric.NewMeterProvider()
+	)
+
+	middleware, err := TelemetryMiddleware(tracerProvider, meterProvider)
+	require.NoError(t, err)
+
+	var inner trace.SpanContext
+
+	tool := Chain(&fakeTool{
+		exec: func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			inner = trace.SpanContextFromContext(ctx)
+
+			return mcp.NewToolResultText("ok"), nil
+		},
+	}, middleware)
+
+	_, err = tool.Exec(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, spans[0].SpanContext(), inner, "Exec must run within the tool span")
+}
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// envTLSCertFile is the PEM certificate the HTTP transports are served with
+	envTLSCertFile = "MCP_TLS_CERT_FILE"
+	// envTLSKeyFile is the PEM private key of the certificate
+	envTLSKeyFile = "MCP_TLS_KEY_FILE"
+	// envTLSClientCAFile is the PEM certificates of the authorities the TLS
+	// client certificates are verified with
+	envTLSClientCAFile = "MCP_TLS_CLIENT_CA_FILE"
+)
+
+// tlsFiles are the files of a TLS configuration
+type tlsFiles struct {
+	certFile string
+	keyFile  string
+	// clientCAFile is optional
+	clientCAFile string
+}
+
+// paths of the files
+func (files tlsFiles) paths() []string {
+	paths := []string{files.certFile, files.keyFile}
+
+	if files.clientCAFile != "" {
+		paths = append(paths, files.clientCAFile)
+	}
+
+	return paths
+}
+
+// fileVersion identify the content of a file by its size and modification
+// time
+type fileVersion struct {
+	size    int64
+	modTime time.Time
+}
+
+// equal tell if both versions are the same
+func (version fileVersion) equal(other fileVersion) bool {
+	return version.size == other.size && version.modTime.Equal(other.modTime)
+}
+
+// statFiles return the current version of each file of paths
+func statFiles(paths []string) ([]fileVersion, error) {
+	versions := make([]fileVersion, len(paths))
+
+	for index, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "os.Stat")
+		}
+
+		versions[index] = fileVersion{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	return versions, nil
+}
+
+// tlsReloader serve a TLS configuration loaded from files, loaded again by
+// Watch when they change. A configuration failing to load is reported to the
+// otel handler and the previous one is kept until the files change again
+type tlsReloader struct {
+	files tlsFiles
+	// interval between checks of the files, defaultWatchInterval when zero
+	interval time.Duration
+	// versions of the files last loaded, successfully or not, only used by
+	// reload
+	versions []fileVersion
+	config   atomic.Pointer[tls.Config]
+}
+
+// newTLSReloader load the files, which must be valid
+func newTLSReloader(files tlsFiles) (*tlsReloader, error) {
+	reloader := &tlsReloader{files: files}
+
+	versions, err := reloader.stat()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := reloader.load()
+	if err != nil {
+		return nil, err
+	}
+
+	reloader.versions = versions
+	reloader.config.Store(config)
+
+	return reloader, nil
+}
+
+// stat return the current version of the files
+func (reloader *tlsReloader) stat() ([]fileVersion, error) {
+	return statFiles(reloader.files.paths())
+}
+
+// load the configuration from the files, verifying the client certificates
+// when given if there is a client CA
+func (reloader *tlsReloader) load() (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(reloader.files.certFile, reloader.files.keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "tls.LoadX509KeyPair")
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+	}
+
+	if reloader.files.clientCAFile == "" {
+		return config, nil
+	}
+
+	content, err := os.ReadFile(reloader.files.clientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(content) {
+		return nil, errors.Errorf("no certificate in client CA %q", reloader.files.clientCAFile)
+	}
+
+	// clients may still authenticate with a token (see MTLSAuthenticator)
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return config, nil
+}
+
+// reload the configuration if the files changed
+func (reloader *tlsReloader) reload() error {
+	versions, err := reloader.stat()
+	if err != nil {
+		return err
+	}
+
+	if slices.EqualFunc(versions, reloader.versions, fileVersion.equal) {
+		return nil
+	}
+
+	reloader.versions = versions
+
+	config, err := reloader.load()
+	if err != nil {
+		return err
+	}
+
+	reloader.config.Store(config)
+
+	return nil
+}
+
+// Watch reload the configuration when the files change, until ctx is done.
+// The failures are reported to the otel handler.
+func (reloader *tlsReloader) Watch(ctx context.Context) {
+	interval := reloader.interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := reloader.reload(); err != nil {
+			otel.Handle(errors.Wrap(err, "reloading TLS configuration"))
+		}
+	}
+}
+
+// getConfigForClient return the last configuration loaded
+func (reloader *tlsReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return reloader.config.Load(), nil
+}
+
+// TLSConfig return a configuration delegating to the reloaded one
+func (reloader *tlsReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: reloader.getConfigForClient,
+	}
+}
+
+// getTLSConfig return the TLS configuration of the HTTP transports from the
+// environment variables MCP_TLS_CERT_FILE, MCP_TLS_KEY_FILE and optionally
+// MCP_TLS_CLIENT_CA_FILE, reloaded when the files change until ctx is done.
+// It is nil when no certificate is configured
+func getTLSConfig(ctx context.Context) (*tls.Config, error) {
+	files := tlsFiles{
+		certFile:     os.Getenv(envTLSCertFile),
+		keyFile:      os.Getenv(envTLSKeyFile),
+		clientCAFile: os.Getenv(envTLSClientCAFile),
+	}
+
+	if files.certFile == "" && files.keyFile == "" {
+		if files.clientCAFile != "" {
+			return nil, errors.Errorf("%q requires %q and %q", envTLSClientCAFile, envTLSCertFile, envTLSKeyFile)
+		}
+
+		//nolint:nilnil
+		return nil, nil
+	}
+
+	if files.certFile == "" || files.keyFile == "" {
+		return nil, errors.Errorf("both %q and %q are required", envTLSCertFile, envTLSKeyFile)
+	}
+
+	reloader, err := newTLSReloader(files)
+	if err != nil {
+		return nil, errors.Wrap(err, "newTLSReloader")
+	}
+
+	go reloader.Watch(ctx)
+
+	return reloader.TLSConfig(), nil
+}
@@ -0,0 +1,311 @@
+package tools
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate is a certificate generated by a test
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+// newTestCertificate create a certificate of commonName for localhost, signed
+// by parent or self-signed when parent is nil
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	var (
+		signer    = template
+		signerKey = key
+	)
+
+	if parent != nil {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// write the certificate and its key in directory, the files being dated
+// modTime so that a rewrite is seen as a change
+func (certificate *testCertificate) write(t *testing.T, directory string, modTime time.Time) tlsFiles {
+	t.Helper()
+
+	files := tlsFiles{
+		certFile: filepath.Join(directory, "tls.crt"),
+		keyFile:  filepath.Join(directory, "tls.key"),
+	}
+
+	require.NoError(t, os.WriteFile(files.certFile, certificate.certPEM, 0o600))
+	require.NoError(t, os.WriteFile(files.keyFile, certificate.keyPEM, 0o600))
+	require.NoError(t, os.Chtimes(files.certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(files.keyFile, modTime, modTime))
+
+	return files
+}
+
+// tlsClientConfig trust the certificates
+func tlsClientConfig(certificates ...*testCertificate) *tls.Config {
+	roots := x509.NewCertPool()
+	for _, certificate := range certificates {
+		roots.AddCert(certificate.certificate)
+	}
+
+	return &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots, ServerName: "localhost"}
+}
+
+func TestGetTLSConfig(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		files     = newTestCertificate(t, "server", nil).write(t, directory, time.Now())
+		invalid   = filepath.Join(directory, "invalid.pem")
+	)
+
+	require.NoError(t, os.WriteFile(invalid, []byte("invalid"), 0o600))
+
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+		clientCA string
+		wantNil  bool
+		wantErr  bool
+	}{
+		{name: "not configured", wantNil: true},
+		{name: "certificate", certFile: files.certFile, keyFile: files.keyFile},
+		{name: "client CA", certFile: files.certFile, keyFile: files.keyFile, clientCA: files.certFile},
+		{name: "missing key", certFile: files.certFile, wantErr: true},
+		{name: "client CA without certificate", clientCA: files.certFile, wantErr: true},
+		{name: "invalid certificate", certFile: invalid, keyFile: files.keyFile, wantErr: true},
+		{name: "missing file", certFile: filepath.Join(directory, "missing"), keyFile: files.keyFile, wantErr: true},
+		{name: "invalid client CA", certFile: files.certFile, keyFile: files.keyFile, clientCA: invalid, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envTLSCertFile, tt.certFile)
+			t.Setenv(envTLSKeyFile, tt.keyFile)
+			t.Setenv(envTLSClientCAFile, tt.clientCA)
+
+			got, err := getTLSConfig(t.Context())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNil, got == nil)
+		})
+	}
+}
+
+func TestTLSReloader(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		first     = newTestCertificate(t, "first", nil)
+		second    = newTestCertificate(t, "second", nil)
+		modTime   = time.Now().Add(-time.Minute)
+	)
+
+	reloader, err := newTLSReloader(first.write(t, directory, modTime))
+	require.NoError(t, err)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", reloader.TLSConfig())
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, listener.Close())
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	// serverName return the common name of the certificate of the server
+	serverName := func() string {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), tlsClientConfig(first, second))
+		require.NoError(t, err)
+
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+
+	assert.Equal(t, "first", serverName())
+
+	// the files are checked by Watch
+	var (
+		ctx, cancel = context.WithCancel(t.Context())
+		watched     = make(chan struct{})
+	)
+
+	reloader.interval = 10 * time.Millisecond
+
+	go func() {
+		defer close(watched)
+
+		reloader.Watch(ctx)
+	}()
+
+	second.write(t, directory, modTime.Add(time.Second))
+	assert.Eventually(t, func() bool {
+		return serverName() == "second"
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-watched
+
+	// an invalid certificate is not loaded
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "tls.crt"), []byte("invalid"), 0o600))
+	require.Error(t, reloader.reload())
+	assert.Equal(t, "second", serverName())
+}
+
+func TestServeTLSClientCertificate(t *testing.T) {
+	var (
+		directory   = t.TempDir()
+		authority   = newTestCertificate(t, "authority", nil)
+		serverFiles = newTestCertificate(t, "server", authority).write(t, directory, time.Now())
+		clientCert  = newTestCertificate(t, "alice", authority)
+		clientCA    = filepath.Join(directory, "ca.crt")
+	)
+
+	require.NoError(t, os.WriteFile(clientCA, authority.certPEM, 0o600))
+
+	os.Clearenv()
+	t.Setenv(envTLSCertFile, serverFiles.certFile)
+	t.Setenv(envTLSKeyFile, serverFiles.keyFile)
+	t.Setenv(envTLSClientCAFile, clientCA)
+
+	tlsConfig, err := getTLSConfig(t.Context())
+	require.NoError(t, err)
+
+	authenticator, err := authenticatorFromEnv()
+	require.NoError(t, err)
+	require.NotNil(t, authenticator)
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newWhoamiTool()}))
+
+	baseURL := startHTTPTransport(t, srv, TransportHTTP, WithTLSConfig(tlsConfig), WithAuthenticator(authenticator))
+
+	tests := []struct {
+		name         string
+		certificates []*testCertificate
+		want         string
+		wantErr      bool
+	}{
+		{name: "without client certificate", wantErr: true},
+		{name: "with client certificate", certificates: []*testCertificate{clientCert}, want: "mtls:alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientConfig := tlsClientConfig(authority)
+			for _, certificate := range tt.certificates {
+				clientConfig.Certificates = append(clientConfig.Certificates, tls.Certificate{
+					Certificate: [][]byte{certificate.certificate.Raw},
+					PrivateKey:  certificate.key,
+				})
+			}
+
+			cli, err := client.NewStreamableHttpClient(
+				baseURL+StreamableHTTPEndpoint,
+				transport.WithHTTPBasicClient(&http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}),
+			)
+			require.NoError(t, err)
+
+			defer func() {
+				assert.NoError(t, cli.Close())
+			}()
+
+			require.NoError(t, cli.Start(t.Context()))
+
+			initRequest := mcp.InitializeRequest{}
+			initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+
+			_, err = cli.Initialize(t.Context(), initRequest)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Name = whoamiToolName
+
+			result, err := cli.CallTool(t.Context(), request)
+			require.NoError(t, err)
+			require.Len(t, result.Content, 1)
+			assert.Equal(t, tt.want, result.Content[0].(mcp.TextContent).Text)
+		})
+	}
+}
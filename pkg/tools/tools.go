@@ -11,6 +11,8 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/pkg/errors"
+
+	"github.com/transform-ia/mcp-tools/pkg/secret"
 )
 
 const (
@@ -19,6 +21,8 @@ const (
 	GetParamError = "GetParam"
 	// GetOptionalParamError wrapping for GetOptionalParam
 	GetOptionalParamError = "GetOptionalParam"
+	// BindArgumentsError wrapping for BindArguments
+	BindArgumentsError = "BindArguments"
 	// ServerAddToolsError wrapping for ServerAddTools
 	ServerAddToolsError = "ServerAddTools"
 )
@@ -31,12 +35,13 @@ type Tool interface {
 }
 
 // TextContentError creates a CallToolResult with an error message formatted as text content.
+// The resolved secrets are redacted from the message (see secret.Redact).
 func TextContentError(err error) *mcp.CallToolResult {
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: fmt.Sprintf("Error: %q", err.Error()),
+				Text: fmt.Sprintf("Error: %q", secret.Redact(err.Error())),
 			},
 		},
 		IsError: true,
@@ -45,7 +50,7 @@ func TextContentError(err error) *mcp.CallToolResult {
 
 // GetParam return a value from a MCP Tool request parameter
 func GetParam[T any](request *mcp.CallToolRequest, paramName string) (*T, error) {
-	untypedValue, exists := request.Params.Arguments[paramName]
+	untypedValue, exists := request.GetArguments()[paramName]
 	if !exists {
 		return nil, errors.Errorf("missing argument %q", paramName)
 	}
@@ -65,7 +70,7 @@ func GetParam[T any](request *mcp.CallToolRequest, paramName string) (*T, error)
 // GetOptionalParam return a value from a MCP Tool request parameter
 // nil if no value in request
 func GetOptionalParam[T any](request *mcp.CallToolRequest, paramName string) (*T, error) {
-	untypedValue, exists := request.Params.Arguments[paramName]
+	untypedValue, exists := request.GetArguments()[paramName]
 	if !exists {
 		//nolint:nilnil
 		return nil, nil
@@ -84,6 +89,8 @@ func GetOptionalParam[T any](request *mcp.CallToolRequest, paramName string) (*T
 }
 
 // WithOptionalJSONOutput create a Tool property to optionally return the output as JSON
+//
+// Deprecated: use Func, which return both a text rendering and a structured content.
 func WithOptionalJSONOutput() mcp.ToolOption {
 	return mcp.WithBoolean(
 		keyIsJSON,
@@ -94,6 +101,8 @@ func WithOptionalJSONOutput() mcp.ToolOption {
 }
 
 // TextRenderOrJSON render a text/template.Template or a JSON string
+//
+// Deprecated: use Func, which return both a text rendering and a structured content.
 func TextRenderOrJSON(data any, tpl *template.Template, request *mcp.CallToolRequest) *mcp.CallToolResult {
 	isJSON, err := GetParam[bool](request, keyIsJSON)
 	if err != nil {
@@ -115,16 +124,34 @@ func TextRenderOrJSON(data any, tpl *template.Template, request *mcp.CallToolReq
 	return mcp.NewToolResultText(buf.String())
 }
 
-// ServerAddTools add to a server initialized Tool
-func ServerAddTools(server *server.MCPServer, tools []Tool) error {
+// ServerAddTools add to a server initialized Tool, each one wrapped by
+// middlewares, the first one being the outermost, and called within the
+// trace context propagated in the _meta of the request (see InjectTraceContext)
+func ServerAddTools(server *server.MCPServer, tools []Tool, middlewares ...Middleware) error {
 	for index, tool := range tools {
-		toolInstance, err := tool.New()
+		serverTool, err := newServerTool(tool, middlewares)
 		if err != nil {
-			return errors.Wrapf(err, "tools[%d:%s].New()", index, tool.Name())
+			return errors.Wrapf(err, "tools[%d:%s]", index, tool.Name())
 		}
 
-		server.AddTool(*toolInstance, tool.Exec)
+		server.AddTools(*serverTool)
 	}
 
 	return nil
 }
+
+// newServerTool create the registration of tool wrapped by middlewares, as
+// done by ServerAddTools
+func newServerTool(tool Tool, middlewares []Middleware) (*server.ServerTool, error) {
+	tool = Chain(tool, middlewares...)
+
+	toolInstance, err := tool.New()
+	if err != nil {
+		return nil, errors.Wrap(err, "New")
+	}
+
+	return &server.ServerTool{
+		Tool:    *toolInstance,
+		Handler: trackInFlight(extractTraceContext(tool.Exec)),
+	}, nil
+}
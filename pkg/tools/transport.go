@@ -0,0 +1,619 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+
+	"github.com/transform-ia/mcp-tools/pkg/telemetry"
+)
+
+// Transports that can be selected with the environment variable MCP_TRANSPORT
+const (
+	// TransportStdio serve MCP over stdin/stdout
+	TransportStdio = "stdio"
+	// TransportSSE serve MCP over HTTP with Server-Sent Events
+	TransportSSE = "sse"
+	// TransportHTTP serve MCP over streamable HTTP
+	TransportHTTP = "http"
+)
+
+const (
+	envPort      = "PORT"
+	envTransport = "MCP_TRANSPORT"
+	// envAdminPort is the port the handlers given to Serve are served on
+	// instead of the port of the transport
+	envAdminPort = "MCP_ADMIN_PORT"
+	// envShutdownTimeout is how long in-flight tool calls are waited for
+	envShutdownTimeout     = "MCP_SHUTDOWN_TIMEOUT"
+	defaultShutdownTimeout = 10 * time.Second
+	// StreamableHTTPEndpoint is the path of the streamable HTTP transport
+	StreamableHTTPEndpoint = "/mcp"
+	// MetricsEndpoint is the path Prometheus scrape the metrics from
+	MetricsEndpoint   = "/metrics"
+	readHeaderTimeout = 10 * time.Second
+)
+
+// GetTransport return the transport Serve select by the environment, when
+// MCP_TRANSPORT is not defined it is SSE if PORT is defined, stdio otherwise
+func GetTransport() (string, error) {
+	transport := os.Getenv(envTransport)
+
+	switch transport {
+	case TransportStdio, TransportSSE, TransportHTTP:
+		return transport, nil
+	case "":
+		if len(os.Getenv(envPort)) != 0 {
+			return TransportSSE, nil
+		}
+
+		return TransportStdio, nil
+	default:
+		return "", errors.Errorf(
+			"invalid transport %q - must be one of %q, %q or %q",
+			transport,
+			TransportStdio,
+			TransportSSE,
+			TransportHTTP,
+		)
+	}
+}
+
+// ServeOption configure Serve
+type ServeOption func(*serveOptions)
+
+// serveOptions of Serve
+type serveOptions struct {
+	// handlers served along the transport, by pattern
+	handlers map[string]http.Handler
+	// healthCheckers checked by the readiness probe, by name
+	healthCheckers map[string]HealthChecker
+	// authenticator of the MCP endpoints, from the environment when nil
+	authenticator Authenticator
+	// tlsConfig of the HTTP transports, from the environment when nil
+	tlsConfig *tls.Config
+	// draining is set once Serve stop, failing the readiness probe
+	draining *atomic.Bool
+}
+
+// newServeOptions return the default options modified by opts, serving the
+// health probes unless their handlers are replaced
+func newServeOptions(opts []ServeOption) *serveOptions {
+	result := &serveOptions{
+		handlers:       map[string]http.Handler{},
+		healthCheckers: map[string]HealthChecker{},
+		draining:       &atomic.Bool{},
+	}
+
+	for _, opt := range opts {
+		opt(result)
+	}
+
+	if _, exists := result.handlers[HealthEndpoint]; !exists {
+		result.handlers[HealthEndpoint] = HealthHandler()
+	}
+
+	if _, exists := result.handlers[ReadyEndpoint]; !exists {
+		result.handlers[ReadyEndpoint] = readyHandler(result.healthCheckers, result.draining)
+	}
+
+	return result
+}
+
+// WithHandler serve handler at pattern (see http.ServeMux) on the port of the
+// HTTP transports, or on MCP_ADMIN_PORT when it is defined. A nil handler is
+// ignored. MetricsEndpoint is only served on MCP_ADMIN_PORT.
+func WithHandler(pattern string, handler http.Handler) ServeOption {
+	return func(opts *serveOptions) {
+		if handler != nil {
+			opts.handlers[pattern] = handler
+		}
+	}
+}
+
+// WithHealthCheckers make the readiness probe check checkers, like the
+// URLHealthCheckers of the configurations of the tools
+func WithHealthCheckers(checkers map[string]HealthChecker) ServeOption {
+	return func(opts *serveOptions) {
+		maps.Copy(opts.healthCheckers, checkers)
+	}
+}
+
+// WithAuthenticator authenticate the callers of the HTTP transports with
+// authenticator instead of the one configured by the environment
+func WithAuthenticator(authenticator Authenticator) ServeOption {
+	return func(opts *serveOptions) {
+		opts.authenticator = authenticator
+	}
+}
+
+// WithTLSConfig serve the HTTP transports over TLS with config instead of the
+// one configured by the environment
+func WithTLSConfig(config *tls.Config) ServeOption {
+	return func(opts *serveOptions) {
+		opts.tlsConfig = config
+	}
+}
+
+// getPort return the TCP port from the environment variable envKey
+func getPort(envKey string) (uint16, error) {
+	portStr := os.Getenv(envKey)
+	if len(portStr) == 0 {
+		return 0, errors.Errorf("missing environment variable %q", envKey)
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return 0, errors.Wrapf(
+			err,
+			"invalid port number %q - must be between 1 and 65535",
+			portStr,
+		)
+	}
+
+	if port < 1 {
+		return 0, errors.Errorf(
+			"invalid port number %d - must be between 1 and 65535",
+			port,
+		)
+	}
+
+	return uint16(port), nil
+}
+
+// getAdminPort return the TCP port from the environment variable
+// MCP_ADMIN_PORT, zero when it is not defined
+func getAdminPort() (uint16, error) {
+	if len(os.Getenv(envAdminPort)) == 0 {
+		return 0, nil
+	}
+
+	return getPort(envAdminPort)
+}
+
+// listen on the TCP port of every interface
+func listen(port uint16) (net.Listener, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.FormatUint(uint64(port), 10)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "net.Listen(%d)", port)
+	}
+
+	return listener, nil
+}
+
+// newServeMux create a mux serving handlers by pattern
+func newServeMux(handlers map[string]http.Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	for pattern, handler := range handlers {
+		mux.Handle(pattern, handler)
+	}
+
+	return mux
+}
+
+// startAdminServer serve handlers on listener, the returned function shutdown
+// the server
+func startAdminServer(listener net.Listener, handlers map[string]http.Handler) func(context.Context) error {
+	var (
+		httpServer = &http.Server{
+			Handler:           newServeMux(handlers),
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+		served = make(chan error, 1)
+	)
+
+	go func() {
+		served <- httpServer.Serve(listener)
+	}()
+
+	return func(ctx context.Context) error {
+		if err := httpServer.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "Shutdown")
+		}
+
+		if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+			return errors.Wrap(err, "Serve")
+		}
+
+		return nil
+	}
+}
+
+// newHTTPServer create the HTTP server of an HTTP transport, also serving
+// handlers by pattern, the MCP endpoints requiring authentication when
+// authenticator is not nil. The returned function shutdown the MCP transport
+// and its HTTP server
+func newHTTPServer(
+	srv *server.MCPServer,
+	transport string,
+	handlers map[string]http.Handler,
+	authenticator Authenticator,
+) (*http.Server, func(context.Context) error, error) {
+	var (
+		mux        = newServeMux(handlers)
+		httpServer = &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+		protect = func(handler http.Handler) http.Handler {
+			if authenticator == nil {
+				return handler
+			}
+
+			return requireAuthentication(handler, authenticator)
+		}
+	)
+
+	switch transport {
+	case TransportSSE:
+		sseServer := server.NewSSEServer(
+			srv,
+			server.WithBasePath("/"),
+			server.WithHTTPServer(httpServer),
+		)
+		var (
+			responses = newSSEResponses(sseServer.CompleteSsePath(), sseServer.CompleteMessagePath())
+			handler   = responses.track(sseServer)
+		)
+
+		if authenticator != nil {
+			sessions := newSessionPrincipals(sseServer.CompleteSsePath(), sseServer.CompleteMessagePath())
+			handler = protect(sessions.bind(handler))
+		}
+
+		mux.Handle("/", handler)
+
+		// the sessions are closed once the responses of the in-flight
+		// requests are sent
+		shutdown := func(ctx context.Context) error {
+			drainErr := responses.drain(ctx)
+
+			if err := sseServer.Shutdown(ctx); err != nil {
+				return errors.Wrap(err, "Shutdown")
+			}
+
+			return drainErr
+		}
+
+		return httpServer, shutdown, nil
+	case TransportHTTP:
+		streamableServer := server.NewStreamableHTTPServer(
+			srv,
+			server.WithEndpointPath(StreamableHTTPEndpoint),
+			server.WithStreamableHTTPServer(httpServer),
+		)
+		mux.Handle(StreamableHTTPEndpoint, protect(streamableServer))
+
+		return httpServer, streamableServer.Shutdown, nil
+	default:
+		return nil, nil, errors.Errorf("transport %q is not served over HTTP", transport)
+	}
+}
+
+// getShutdownTimeout return how long in-flight tool calls are waited for on
+// shutdown, from the environment variable MCP_SHUTDOWN_TIMEOUT
+func getShutdownTimeout() (time.Duration, error) {
+	timeoutStr := os.Getenv(envShutdownTimeout)
+	if len(timeoutStr) == 0 {
+		return defaultShutdownTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid shutdown timeout %q", timeoutStr)
+	}
+
+	if timeout <= 0 {
+		return 0, errors.Errorf("invalid shutdown timeout %q - must be positive", timeoutStr)
+	}
+
+	return timeout, nil
+}
+
+// serveHTTP serve a MCP server over an HTTP transport and the handlers of
+// options on listener until ctx is done, then stop accepting sessions and wait
+// up to timeout for in-flight calls
+func serveHTTP(
+	ctx context.Context,
+	srv *server.MCPServer,
+	transport string,
+	options *serveOptions,
+	listener net.Listener,
+	timeout time.Duration,
+) error {
+	httpServer, shutdown, err := newHTTPServer(srv, transport, options.handlers, options.authenticator)
+	if err != nil {
+		return errors.Wrap(err, "newHTTPServer")
+	}
+
+	var (
+		calls  = newInFlight()
+		served = make(chan error, 1)
+	)
+
+	// requests must outlive ctx to let in-flight calls finish after a signal
+	httpServer.BaseContext = func(net.Listener) context.Context {
+		return withInFlight(context.WithoutCancel(ctx), calls)
+	}
+
+	go func() {
+		served <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err = <-served:
+		return errors.Wrap(err, "Serve")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	if err = shutdown(shutdownCtx); err != nil {
+		return errors.Wrap(err, "Shutdown")
+	}
+
+	if err = calls.wait(shutdownCtx); err != nil {
+		return errors.Wrap(err, "wait")
+	}
+
+	if err = <-served; !errors.Is(err, http.ErrServerClosed) {
+		return errors.Wrap(err, "Serve")
+	}
+
+	return nil
+}
+
+// serveStdio serve a MCP server over stdin and stdout until stdin is closed or
+// ctx is done, then stop reading stdin and wait up to timeout for in-flight
+// calls. stdin is closed on return if it is an io.Closer
+func serveStdio(ctx context.Context, srv *server.MCPServer, stdin io.Reader, stdout io.Writer, timeout time.Duration) error {
+	var (
+		pipeReader, pipeWriter = io.Pipe()
+		// tool calls must outlive ctx to finish after a signal, the stdio
+		// server wait for its in-flight calls before Listen return
+		listenCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+		stopped           = make(chan struct{})
+	)
+	defer cancel()
+
+	// stdin is read through a pipe that can be closed to stop reading requests
+	go func() {
+		_, err := io.Copy(pipeWriter, stdin)
+		pipeWriter.CloseWithError(err)
+	}()
+
+	// the goroutine reading stdin return once both are closed
+	defer func() {
+		_ = pipeReader.Close()
+
+		if closer, isCloser := stdin.(io.Closer); isCloser {
+			_ = closer.Close()
+		}
+	}()
+
+	go func() {
+		select {
+		case <-stopped:
+			return
+		case <-ctx.Done():
+		}
+
+		pipeWriter.CloseWithError(io.EOF)
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-stopped:
+		case <-timer.C:
+			cancel()
+		}
+	}()
+
+	err := server.NewStdioServer(srv).Listen(listenCtx, pipeReader, stdout)
+	close(stopped)
+
+	if err != nil {
+		return errors.Wrap(err, "Listen")
+	}
+
+	if err = listenCtx.Err(); err != nil {
+		return errors.Wrap(err, "waiting for in-flight tool calls")
+	}
+
+	return nil
+}
+
+// Serve serve a MCP server over the transport selected by the environment
+// variable MCP_TRANSPORT: "stdio", "sse" or "http" (streamable HTTP).
+// If MCP_TRANSPORT is not defined, SSE is used when the environment variable
+// PORT is defined and stdio otherwise. HTTP transports listen on PORT.
+//
+// The health probes at HealthEndpoint and ReadyEndpoint (see
+// WithHealthCheckers) and the handlers given by WithHandler are served on the
+// port of the HTTP transports, or on a port of their own when the environment
+// variable MCP_ADMIN_PORT is defined, which is the only way to serve them
+// along stdio. The Prometheus metrics of the telemetry carried by ctx (see
+// telemetry.FromContext) are served at MetricsEndpoint on MCP_ADMIN_PORT only,
+// as they are not authenticated.
+//
+// The callers of the HTTP transports are authenticated by the Authenticator
+// of WithAuthenticator, or by the one configured by the environment: static
+// bearer tokens MCP_AUTH_TOKEN_<principal>=<token>, JWT verified with the
+// JWKS file MCP_AUTH_JWKS_FILE (and MCP_AUTH_JWT_ISSUER and
+// MCP_AUTH_JWT_AUDIENCE when defined) and TLS client certificates. The
+// principal is carried by the context given to the tools (see
+// PrincipalFromContext), and the messages of an SSE session are only
+// accepted from the principal that opened it. Without configuration, callers
+// are not authenticated.
+//
+// The HTTP transports are served over TLS with the configuration of
+// WithTLSConfig, or with the certificate MCP_TLS_CERT_FILE and its key
+// MCP_TLS_KEY_FILE, the client certificates being verified with the
+// authorities MCP_TLS_CLIENT_CA_FILE when defined. The files are checked
+// every 5 seconds until Serve return and reloaded when they change. The
+// handlers on MCP_ADMIN_PORT are served without TLS. Over stdio, the
+// authentication and TLS variables are ignored.
+//
+// Serve return when ctx is done or on SIGINT/SIGTERM, once new sessions are
+// refused and the in-flight tool calls are finished, waiting for them at most
+// MCP_SHUTDOWN_TIMEOUT (default 10s). The readiness probe fails meanwhile.
+//
+// Over stdio, the console telemetry exporters are redirected to stderr (see
+// telemetry.ProtectStdout) until Serve return, to keep stdout for the protocol.
+func Serve(ctx context.Context, srv *server.MCPServer, opts ...ServeOption) error {
+	options := newServeOptions(opts)
+
+	transport, err := GetTransport()
+	if err != nil {
+		return errors.Wrap(err, "GetTransport")
+	}
+
+	timeout, err := getShutdownTimeout()
+	if err != nil {
+		return errors.Wrap(err, "getShutdownTimeout")
+	}
+
+	adminPort, err := getAdminPort()
+	if err != nil {
+		return errors.Wrap(err, "getAdminPort")
+	}
+
+	// the TLS files are watched until Serve return
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if transport != TransportStdio {
+		if err = withHTTPSecurity(ctx, options); err != nil {
+			return err
+		}
+	}
+
+	withMetrics(ctx, options, adminPort)
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// the load balancers must stop sending requests while the calls drain
+	stopDraining := context.AfterFunc(ctx, func() {
+		options.draining.Store(true)
+	})
+	defer stopDraining()
+
+	if adminPort == 0 {
+		return serve(ctx, srv, transport, options, timeout)
+	}
+
+	listener, err := listen(adminPort)
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+
+	shutdownAdmin := startAdminServer(listener, options.handlers)
+
+	// the handlers are served on the admin port only
+	transportOptions := *options
+	transportOptions.handlers = nil
+
+	err = serve(ctx, srv, transport, &transportOptions, timeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	if shutdownErr := shutdownAdmin(shutdownCtx); shutdownErr != nil && err == nil {
+		return errors.Wrap(shutdownErr, "shutdownAdmin")
+	}
+
+	return err
+}
+
+// withHTTPSecurity set the authenticator and the TLS configuration of the
+// HTTP transports from the environment, unless set by options
+func withHTTPSecurity(ctx context.Context, options *serveOptions) error {
+	var err error
+
+	if options.authenticator == nil {
+		if options.authenticator, err = authenticatorFromEnv(); err != nil {
+			return errors.Wrap(err, "authenticatorFromEnv")
+		}
+	}
+
+	if options.tlsConfig == nil {
+		if options.tlsConfig, err = getTLSConfig(ctx); err != nil {
+			return errors.Wrap(err, "getTLSConfig")
+		}
+	}
+
+	return nil
+}
+
+// withMetrics serve the metrics of the telemetry carried by ctx unless
+// WithHandler serve MetricsEndpoint, and only when there is an admin port
+func withMetrics(ctx context.Context, options *serveOptions, adminPort uint16) {
+	if tel := telemetry.FromContext(ctx); tel != nil && tel.MetricsHandler != nil {
+		if _, exists := options.handlers[MetricsEndpoint]; !exists {
+			options.handlers[MetricsEndpoint] = tel.MetricsHandler
+		}
+	}
+
+	if _, exists := options.handlers[MetricsEndpoint]; exists && adminPort == 0 {
+		telemetry.LoggerFromContext(ctx).WarnContext(ctx, "metrics not served without "+envAdminPort)
+
+		delete(options.handlers, MetricsEndpoint)
+	}
+}
+
+// serve a MCP server over transport, along the handlers of options for the
+// HTTP transports
+func serve(
+	ctx context.Context,
+	srv *server.MCPServer,
+	transport string,
+	options *serveOptions,
+	timeout time.Duration,
+) error {
+	if transport == TransportStdio {
+		// stdout carry the protocol, console telemetry must not write to it
+		defer telemetry.ProtectStdout()()
+
+		if err := serveStdio(ctx, srv, os.Stdin, os.Stdout, timeout); err != nil {
+			return errors.Wrap(err, "serveStdio")
+		}
+
+		return nil
+	}
+
+	port, err := getPort(envPort)
+	if err != nil {
+		return errors.Wrap(err, "getPort")
+	}
+
+	listener, err := listen(port)
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+
+	if options.tlsConfig != nil {
+		listener = tls.NewListener(listener, options.tlsConfig)
+	}
+
+	if err = serveHTTP(ctx, srv, transport, options, listener, timeout); err != nil {
+		return errors.Wrap(err, "serveHTTP")
+	}
+
+	return nil
+}
@@ -0,0 +1,834 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/transform-ia/mcp-tools/pkg/telemetry"
+)
+
+const (
+	echoToolName     = "echo"
+	echoArgumentText = "text"
+	blockingToolName = "blocking"
+	released         = "released"
+)
+
+// newEchoTool create a Tool that reply with its text argument
+func newEchoTool() *fakeTool {
+	return &fakeTool{
+		name: echoToolName,
+		options: withOptions(
+			mcp.WithDescription("Reply with the text argument"),
+			mcp.WithString(echoArgumentText, mcp.Required()),
+		),
+		exec: func(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			text, err := GetParam[string](&request, echoArgumentText)
+			if err != nil {
+				return TextContentError(err), nil
+			}
+
+			return mcp.NewToolResultText(*text), nil
+		},
+	}
+}
+
+// newBlockingTool create a Tool whose calls signal started then wait for
+// release to be closed
+func newBlockingTool() (*fakeTool, chan struct{}, chan struct{}) {
+	var (
+		started = make(chan struct{}, 1)
+		release = make(chan struct{})
+	)
+
+	tool := &fakeTool{
+		name: blockingToolName,
+		exec: func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			started <- struct{}{}
+			<-release
+
+			return mcp.NewToolResultText(released), nil
+		},
+	}
+
+	return tool, started, release
+}
+
+func newEchoServer(t *testing.T) *server.MCPServer {
+	t.Helper()
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newEchoTool()}))
+
+	return srv
+}
+
+func newBlockingServer(t *testing.T, tool Tool) *server.MCPServer {
+	t.Helper()
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{tool}))
+
+	return srv
+}
+
+func initializeClient(t *testing.T, cli *client.Client) *mcp.InitializeResult {
+	t.Helper()
+
+	require.NoError(t, cli.Start(t.Context()))
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test-client", Version: "1.0.0"}
+
+	initResult, err := cli.Initialize(t.Context(), initRequest)
+	require.NoError(t, err)
+
+	return initResult
+}
+
+// assertEchoCall initialize a client and call the echo tool through it
+func assertEchoCall(t *testing.T, cli *client.Client) {
+	t.Helper()
+
+	initResult := initializeClient(t, cli)
+	assert.Equal(t, "test", initResult.ServerInfo.Name)
+
+	callRequest := mcp.CallToolRequest{}
+	callRequest.Params.Name = echoToolName
+	callRequest.Params.Arguments = map[string]any{echoArgumentText: "hello"}
+
+	result, err := cli.CallTool(t.Context(), callRequest)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Len(t, result.Content, 1)
+
+	text, isText := mcp.AsTextContent(result.Content[0])
+	require.True(t, isText)
+	assert.Equal(t, "hello", text.Text)
+}
+
+// startHTTPTransport serve srv as configured by opts on a loopback port and
+// return its base URL
+func startHTTPTransport(t *testing.T, srv *server.MCPServer, transportName string, opts ...ServeOption) string {
+	t.Helper()
+
+	var (
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		scheme        = "http://"
+	)
+	require.NoError(t, err)
+
+	options := newServeOptions(opts)
+	if options.tlsConfig != nil {
+		listener = tls.NewListener(listener, options.tlsConfig)
+		scheme = "https://"
+	}
+
+	httpServer, shutdown, err := newHTTPServer(srv, transportName, options.handlers, options.authenticator)
+	require.NoError(t, err)
+
+	go func() {
+		_ = httpServer.Serve(listener)
+	}()
+
+	t.Cleanup(func() {
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	return scheme + listener.Addr().String()
+}
+
+func TestGetTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport string
+		port      string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "default to stdio",
+			want: TransportStdio,
+		},
+		{
+			name: "default to sse with a port",
+			port: "8080",
+			want: TransportSSE,
+		},
+		{
+			name:      "explicit http",
+			transport: TransportHTTP,
+			port:      "8080",
+			want:      TransportHTTP,
+		},
+		{
+			name:      "explicit stdio with a port",
+			transport: TransportStdio,
+			port:      "8080",
+			want:      TransportStdio,
+		},
+		{
+			name:      "invalid transport",
+			transport: "websocket",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envTransport, tt.transport)
+			t.Setenv(envPort, tt.port)
+
+			got, err := GetTransport()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    string
+		want    uint16
+		wantErr bool
+	}{
+		{
+			name: "valid port",
+			port: "8080",
+			want: 8080,
+		},
+		{
+			name:    "missing port",
+			wantErr: true,
+		},
+		{
+			name:    "invalid port",
+			port:    "invalid",
+			wantErr: true,
+		},
+		{
+			name:    "port zero",
+			port:    "0",
+			wantErr: true,
+		},
+		{
+			name:    "port out of range",
+			port:    "65536",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envPort, tt.port)
+
+			got, err := getPort(envPort)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	tests := []struct {
+		name            string
+		transport       string
+		port            string
+		adminPort       string
+		shutdownTimeout string
+	}{
+		{
+			name: "invalid port",
+			port: "invalid",
+		},
+		{
+			name:      "http without port",
+			transport: TransportHTTP,
+		},
+		{
+			name:      "invalid transport",
+			transport: "websocket",
+		},
+		{
+			name:            "invalid shutdown timeout",
+			shutdownTimeout: "soon",
+		},
+		{
+			name:      "invalid admin port",
+			transport: TransportStdio,
+			adminPort: "invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envTransport, tt.transport)
+			t.Setenv(envPort, tt.port)
+			t.Setenv(envShutdownTimeout, tt.shutdownTimeout)
+			t.Setenv(envAdminPort, tt.adminPort)
+			t.Setenv(envAuthJWKSFile, "")
+			t.Setenv(envTLSCertFile, "")
+			t.Setenv(envTLSKeyFile, "")
+			t.Setenv(envTLSClientCAFile, "")
+
+			require.Error(t, Serve(t.Context(), newEchoServer(t)))
+		})
+	}
+}
+
+func TestServeSSE(t *testing.T) {
+	baseURL := startHTTPTransport(t, newEchoServer(t), TransportSSE)
+
+	cli, err := client.NewSSEMCPClient(baseURL + "/sse")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	assertEchoCall(t, cli)
+}
+
+func TestServeStreamableHTTP(t *testing.T) {
+	baseURL := startHTTPTransport(t, newEchoServer(t), TransportHTTP)
+
+	cli, err := client.NewStreamableHttpClient(baseURL + StreamableHTTPEndpoint)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	assertEchoCall(t, cli)
+}
+
+func TestServeStreamableHTTPNotFound(t *testing.T) {
+	baseURL := startHTTPTransport(t, newEchoServer(t), TransportHTTP)
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, baseURL+"/sse", nil)
+	require.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+// getBody GET url and return its body
+func getBody(t *testing.T, url string) string {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, response.Body.Close())
+	}()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestServeMetrics(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "none")
+	t.Setenv("OTEL_METRICS_EXPORTER", "none")
+	t.Setenv("OTEL_LOGS_EXPORTER", "none")
+
+	tel, err := telemetry.InitTelemetry(t.Context(), "test", "1.0.0", telemetry.WithoutGlobals(), telemetry.WithPrometheus())
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, tel.Shutdown(context.Background()))
+	}()
+
+	middleware, err := TelemetryMiddleware(tel.TracerProvider, tel.MeterProvider)
+	require.NoError(t, err)
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newEchoTool()}, middleware))
+
+	options := newServeOptions(nil)
+	withMetrics(telemetry.ContextWithTelemetry(t.Context(), tel), options, 9090)
+
+	adminListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	shutdownAdmin := startAdminServer(adminListener, options.handlers)
+
+	defer func() {
+		assert.NoError(t, shutdownAdmin(context.Background()))
+	}()
+
+	baseURL := startHTTPTransport(t, srv, TransportHTTP)
+
+	cli, err := client.NewStreamableHttpClient(baseURL + StreamableHTTPEndpoint)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, cli.Close())
+	}()
+
+	assertEchoCall(t, cli)
+
+	metrics := getBody(t, "http://"+adminListener.Addr().String()+MetricsEndpoint)
+	assert.Contains(t, metrics, `mcp_tool_calls_total{`)
+	assert.Contains(t, metrics, `mcp_tool_name="echo"`)
+	assert.Contains(t, metrics, "go_goroutines")
+}
+
+func TestWithMetrics(t *testing.T) {
+	var (
+		metricsHandler = http.NewServeMux()
+		ctx            = telemetry.ContextWithTelemetry(t.Context(), &telemetry.Telemetry{MetricsHandler: metricsHandler})
+		custom         = http.NewServeMux()
+	)
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		opts      []ServeOption
+		adminPort uint16
+		want      http.Handler
+	}{
+		{name: "without telemetry", ctx: t.Context(), adminPort: 9090},
+		{name: "telemetry", ctx: ctx, adminPort: 9090, want: metricsHandler},
+		{name: "handler", ctx: ctx, opts: []ServeOption{WithHandler(MetricsEndpoint, custom)}, adminPort: 9090, want: custom},
+		{name: "without admin port", ctx: ctx, opts: []ServeOption{WithHandler(MetricsEndpoint, custom)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := newServeOptions(tt.opts)
+			withMetrics(tt.ctx, options, tt.adminPort)
+
+			if tt.want == nil {
+				assert.NotContains(t, options.handlers, MetricsEndpoint)
+			} else {
+				assert.Same(t, tt.want, options.handlers[MetricsEndpoint])
+			}
+		})
+	}
+}
+
+func TestWithHandlerNil(t *testing.T) {
+	options := newServeOptions([]ServeOption{WithHandler(MetricsEndpoint, nil)})
+
+	assert.NotContains(t, options.handlers, MetricsEndpoint)
+}
+
+func TestStartAdminServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	shutdown := startAdminServer(listener, map[string]http.Handler{
+		MetricsEndpoint: http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(writer, "metrics")
+		}),
+	})
+
+	assert.Equal(t, "metrics", getBody(t, "http://"+listener.Addr().String()+MetricsEndpoint))
+	require.NoError(t, shutdown(t.Context()))
+}
+
+func TestServeStdio(t *testing.T) {
+	var (
+		serverReader, clientWriter = io.Pipe()
+		clientReader, serverWriter = io.Pipe()
+		served                     = make(chan error, 1)
+	)
+
+	go func() {
+		served <- serveStdio(t.Context(), newEchoServer(t), serverReader, serverWriter, time.Second)
+	}()
+
+	cli := client.NewClient(transport.NewIO(clientReader, clientWriter, io.NopCloser(nil)))
+
+	assertEchoCall(t, cli)
+
+	require.NoError(t, cli.Close())
+	require.NoError(t, serverWriter.Close())
+	require.NoError(t, <-served)
+}
+
+func TestGetShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: defaultShutdownTimeout,
+		},
+		{
+			name:    "valid duration",
+			timeout: "1m",
+			want:    time.Minute,
+		},
+		{
+			name:    "invalid duration",
+			timeout: "soon",
+			wantErr: true,
+		},
+		{
+			name:    "negative duration",
+			timeout: "-1s",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envShutdownTimeout, tt.timeout)
+
+			got, err := getShutdownTimeout()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type callResult struct {
+	result *mcp.CallToolResult
+	err    error
+}
+
+// callBlockingTool call the blocking tool in the background
+func callBlockingTool(t *testing.T, cli *client.Client) <-chan callResult {
+	t.Helper()
+
+	initializeClient(t, cli)
+
+	results := make(chan callResult, 1)
+
+	go func() {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = blockingToolName
+
+		result, err := cli.CallTool(context.Background(), request)
+		results <- callResult{result: result, err: err}
+	}()
+
+	return results
+}
+
+func assertReleased(t *testing.T, call callResult) {
+	t.Helper()
+
+	require.NoError(t, call.err)
+	require.Len(t, call.result.Content, 1)
+
+	text, isText := mcp.AsTextContent(call.result.Content[0])
+	require.True(t, isText)
+	assert.Equal(t, released, text.Text)
+}
+
+func TestServeHTTPGracefulShutdown(t *testing.T) {
+	for _, transportName := range []string{TransportSSE, TransportHTTP} {
+		t.Run(transportName, func(t *testing.T) {
+			var (
+				tool, started, release = newBlockingTool()
+				ctx, cancel            = context.WithCancel(t.Context())
+				served                 = make(chan error, 1)
+			)
+			defer cancel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			go func() {
+				served <- serveHTTP(ctx, newBlockingServer(t, tool), transportName, newServeOptions(nil), listener, 5*time.Second)
+			}()
+
+			baseURL := "http://" + listener.Addr().String()
+
+			var cli *client.Client
+			if transportName == TransportSSE {
+				cli, err = client.NewSSEMCPClient(baseURL + "/sse")
+			} else {
+				cli, err = client.NewStreamableHttpClient(baseURL + StreamableHTTPEndpoint)
+			}
+
+			require.NoError(t, err)
+
+			defer func() {
+				_ = cli.Close()
+			}()
+
+			results := callBlockingTool(t, cli)
+
+			<-started
+			cancel()
+
+			select {
+			case err = <-served:
+				t.Fatalf("serveHTTP returned before the in-flight call finished: %v", err)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			if transportName == TransportSSE {
+				response, err := http.Get(baseURL + "/sse") //nolint:noctx
+				require.NoError(t, err)
+				assert.NoError(t, response.Body.Close())
+				assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode, "new sessions must be refused")
+			}
+
+			close(release)
+			require.NoError(t, <-served)
+
+			// the result of the drained call is delivered before the session is closed
+			assertReleased(t, <-results)
+
+			_, err = net.Dial("tcp", listener.Addr().String())
+			assert.Error(t, err, "new connections must be refused after shutdown")
+		})
+	}
+}
+
+func TestServeHTTPShutdownTimeout(t *testing.T) {
+	var (
+		tool, started, release = newBlockingTool()
+		ctx, cancel            = context.WithCancel(t.Context())
+		served                 = make(chan error, 1)
+	)
+	defer cancel()
+	defer close(release)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		served <- serveHTTP(ctx, newBlockingServer(t, tool), TransportSSE, newServeOptions(nil), listener, 50*time.Millisecond)
+	}()
+
+	cli, err := client.NewSSEMCPClient("http://" + listener.Addr().String() + "/sse")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	callBlockingTool(t, cli)
+
+	<-started
+	cancel()
+
+	err = <-served
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServeStdioGracefulShutdown(t *testing.T) {
+	var (
+		tool, started, release     = newBlockingTool()
+		ctx, cancel                = context.WithCancel(t.Context())
+		serverReader, clientWriter = io.Pipe()
+		clientReader, serverWriter = io.Pipe()
+		served                     = make(chan error, 1)
+	)
+	defer cancel()
+
+	go func() {
+		served <- serveStdio(ctx, newBlockingServer(t, tool), serverReader, serverWriter, 5*time.Second)
+	}()
+
+	cli := client.NewClient(transport.NewIO(clientReader, clientWriter, io.NopCloser(nil)))
+
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	results := callBlockingTool(t, cli)
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		t.Fatalf("serveStdio returned before the in-flight call finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	assertReleased(t, <-results)
+	require.NoError(t, <-served)
+
+	// stdin is closed not to leak the goroutine reading it
+	_, err := clientWriter.Write([]byte("{}\n"))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+// swapStdio replace os.Stdin, os.Stdout and os.Stderr for the test, returning
+// the writer of stdin, the reader of stdout and the file of stderr
+func swapStdio(t *testing.T) (*os.File, *os.File, *os.File) {
+	t.Helper()
+
+	stdinReader, stdinWriter, err := os.Pipe()
+	require.NoError(t, err)
+
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	require.NoError(t, err)
+
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	require.NoError(t, err)
+
+	stdin, stdout, previousStderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = stdinReader, stdoutWriter, stderr
+
+	t.Cleanup(func() {
+		os.Stdin, os.Stdout, os.Stderr = stdin, stdout, previousStderr
+
+		for _, file := range []*os.File{stdinReader, stdinWriter, stdoutReader, stdoutWriter, stderr} {
+			_ = file.Close()
+		}
+	})
+
+	return stdinWriter, stdoutReader, stderr
+}
+
+// TestServeStdioWithoutHTTPSecurity check the authentication and TLS
+// variables of the HTTP transports do not break stdio
+func TestServeStdioWithoutHTTPSecurity(t *testing.T) {
+	t.Setenv(envTransport, TransportStdio)
+	t.Setenv(envTLSCertFile, filepath.Join(t.TempDir(), "missing.crt"))
+	t.Setenv(envAuthJWKSFile, filepath.Join(t.TempDir(), "missing.json"))
+
+	stdin, _, _ := swapStdio(t)
+	require.NoError(t, stdin.Close())
+
+	require.NoError(t, Serve(t.Context(), newEchoServer(t)))
+}
+
+// TestServeStdioConsoleTelemetry check the console exporters do not write to
+// stdout while it carry the stdio transport
+func TestServeStdioConsoleTelemetry(t *testing.T) {
+	t.Setenv(envTransport, TransportStdio)
+	t.Setenv("OTEL_TRACES_EXPORTER", "console")
+	t.Setenv("OTEL_METRICS_EXPORTER", "none")
+	t.Setenv("OTEL_LOGS_EXPORTER", "console")
+
+	stdin, stdout, stderr := swapStdio(t)
+
+	tel, err := telemetry.InitTelemetry(t.Context(), "test", "1.0.0", telemetry.WithoutGlobals())
+	require.NoError(t, err)
+
+	middleware, err := TelemetryMiddleware(tel.TracerProvider, tel.MeterProvider)
+	require.NoError(t, err)
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	require.NoError(t, ServerAddTools(srv, []Tool{newEchoTool()}, middleware))
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- Serve(t.Context(), srv)
+	}()
+
+	var (
+		requests = json.NewEncoder(stdin)
+		lines    = bufio.NewScanner(stdout)
+	)
+
+	// readResponse read a line of stdout, which must be a JSON-RPC response
+	readResponse := func(id int) {
+		t.Helper()
+
+		require.True(t, lines.Scan(), "missing response %d", id)
+
+		var response struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      int             `json:"id"`
+			Result  json.RawMessage `json:"result"`
+		}
+
+		require.NoError(t, json.Unmarshal(lines.Bytes(), &response), "stdout: %s", lines.Text())
+		assert.Equal(t, mcp.JSONRPC_VERSION, response.JSONRPC)
+		assert.Equal(t, id, response.ID)
+		assert.NotEmpty(t, response.Result)
+	}
+
+	require.NoError(t, requests.Encode(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  "initialize",
+		"params": map[string]any{
+			"protocolVersion": mcp.LATEST_PROTOCOL_VERSION,
+			"clientInfo":      map[string]any{"name": "test-client", "version": "1.0.0"},
+		},
+	}))
+	readResponse(1)
+
+	require.NoError(t, requests.Encode(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"method":  "notifications/initialized",
+	}))
+	require.NoError(t, requests.Encode(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      2,
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      echoToolName,
+			"arguments": map[string]any{echoArgumentText: "hello"},
+		},
+	}))
+	readResponse(2)
+
+	// export while the transport is served
+	tel.Logger.InfoContext(t.Context(), "served")
+	require.NoError(t, tel.TracerProvider.ForceFlush(t.Context()))
+	require.NoError(t, tel.LoggerProvider.ForceFlush(t.Context()))
+
+	require.NoError(t, stdin.Close())
+	require.NoError(t, <-served)
+	require.NoError(t, tel.Shutdown(t.Context()))
+	require.NoError(t, os.Stdout.Close())
+
+	assert.False(t, lines.Scan(), "unexpected output on stdout: %s", lines.Text())
+
+	written, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(written), `"Name":"echo"`)
+	assert.Contains(t, string(written), `"served"`)
+}